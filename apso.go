@@ -0,0 +1,341 @@
+package setpso
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+/*
+Adaptation is the rule used by APso to change the heuristics hu of a group given
+the fraction success of the group's particle updates that improved their
+Personal-best over the last window of updates. group is the group's index,
+which lets a rule keep state for each group. A rule that keeps state should
+also implement encoding.BinaryMarshaler and encoding.BinaryUnmarshaler so that
+the state is saved by APso checkpoints.
+*/
+type Adaptation interface {
+	Adapt(group int, hu *PsoHeuristics, success float64, rnd *rand.Rand)
+	// About gives a description of the rule.
+	About() string
+}
+
+// HeuristicRange gives the range Low to High that a floating point heuristic
+// with index Index is kept in while adapting.
+type HeuristicRange struct {
+	Index     int
+	Low, High float64
+}
+
+/*
+OneFifthRule is the 1/5th success rule. When the success rate is above Target,
+which is normally 0.2, each heuristic in Ranges is multiplied by Factor,
+otherwise it is divided by Factor. The heuristic is then clamped to its range.
+Factor should be greater than 1.0.
+*/
+type OneFifthRule struct {
+	Ranges []HeuristicRange
+	Factor float64
+	Target float64
+}
+
+// Adapt applies the 1/5th success rule to hu.
+func (r *OneFifthRule) Adapt(group int, hu *PsoHeuristics, success float64, rnd *rand.Rand) {
+	for _, hr := range r.Ranges {
+		v := hu.Float(hr.Index)
+		if success > r.Target {
+			v *= r.Factor
+		} else {
+			v /= r.Factor
+		}
+		hu.SetFloat(hr.Index, math.Min(math.Max(v, hr.Low), hr.High))
+	}
+}
+
+// About gives a description of the rule.
+func (r *OneFifthRule) About() string {
+	return fmt.Sprintf("success rule with target %g and factor %g on %v",
+		r.Target, r.Factor, r.Ranges)
+}
+
+/*
+BanditRule treats each setting in Arms as an arm of a multi-armed bandit with
+the success rate as the reward and chooses between them using UCB1 with
+exploration gain C. Arms[a][k] is the value given to the floating point
+heuristic with index Indexes[k] when arm a is chosen. Each group has its own
+bandit.
+*/
+type BanditRule struct {
+	Indexes []int
+	Arms    [][]float64
+	C       float64
+	// bandit state of each group
+	bandits []bandit
+}
+
+// bandit is the state of a bandit for one group.
+type bandit struct {
+	// arm in use; -1 before the first choice
+	arm int
+	// number of plays of each arm
+	plays []int
+	// mean reward of each arm
+	mean []float64
+	// total number of plays
+	total int
+}
+
+// Adapt rewards the arm in use with success and sets hu to the next arm.
+func (r *BanditRule) Adapt(group int, hu *PsoHeuristics, success float64, rnd *rand.Rand) {
+	for len(r.bandits) <= group {
+		r.bandits = append(r.bandits, bandit{arm: -1,
+			plays: make([]int, len(r.Arms)), mean: make([]float64, len(r.Arms))})
+	}
+	b := &r.bandits[group]
+	if b.arm >= 0 {
+		b.plays[b.arm]++
+		b.total++
+		b.mean[b.arm] += (success - b.mean[b.arm]) / float64(b.plays[b.arm])
+	}
+	b.arm = -1
+	best := math.Inf(-1)
+	for a := range r.Arms {
+		if b.plays[a] == 0 {
+			b.arm = a
+			break
+		}
+		u := b.mean[a] + r.C*math.Sqrt(2*math.Log(float64(b.total))/float64(b.plays[a]))
+		if u > best {
+			best = u
+			b.arm = a
+		}
+	}
+	for k, i := range r.Indexes {
+		hu.SetFloat(i, r.Arms[b.arm][k])
+	}
+}
+
+// banditState is the stored form of a bandit.
+type banditState struct {
+	Arm   int
+	Plays []int
+	Mean  []float64
+	Total int
+}
+
+// MarshalBinary returns the bandit state of each group.
+func (r *BanditRule) MarshalBinary() ([]byte, error) {
+	st := make([]banditState, len(r.bandits))
+	for k, b := range r.bandits {
+		st[k] = banditState{b.arm, b.plays, b.mean, b.total}
+	}
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(st)
+	return buf.Bytes(), err
+}
+
+// UnmarshalBinary replaces the bandit state of each group by one returned by
+// MarshalBinary().
+func (r *BanditRule) UnmarshalBinary(data []byte) error {
+	var st []banditState
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&st); err != nil {
+		return err
+	}
+	for _, b := range st {
+		if len(b.Plays) != len(r.Arms) || len(b.Mean) != len(r.Arms) {
+			return fmt.Errorf("bandit state has %d arms not %d", len(b.Plays), len(r.Arms))
+		}
+	}
+	r.bandits = make([]bandit, len(st))
+	for k, b := range st {
+		r.bandits[k] = bandit{b.Arm, b.Plays, b.Mean, b.Total}
+	}
+	return nil
+}
+
+// About gives a description of the rule.
+func (r *BanditRule) About() string {
+	return fmt.Sprintf("UCB1 bandit with gain %g over settings %v of heuristics %v",
+		r.C, r.Arms, r.Indexes)
+}
+
+// AdaptRecord is a record of the heuristics of a group after adapting.
+type AdaptRecord struct {
+	// update count when adapted
+	Update int
+	// group index
+	Group int
+	// success rate that was adapted to
+	Success float64
+	// heuristics after adapting
+	Float []float64
+	Int   []int
+}
+
+/*
+APso is an adaptive SPSO that splits the particles into groups that each have
+their own copy of the heuristics and target the global best. Every Window
+updates each group's heuristics are changed by an Adaptation according to how
+often the group's particle updates improved their Personal-best. Each change is
+recorded in the adaption log and can also be written to an io.Writer so that
+good settings can be reused. Note the master heuristics, which schedules act
+on, are only used for ThresholdHeuristic and NTriesHeuristic.
+*/
+type APso struct {
+	*Pso
+	rule Adaptation
+	// number of groups
+	ngroups int
+	// number of updates between adaptions
+	Window int
+	// count of updates since the last adaption
+	count int
+	// number of improving particle updates of each group since the last
+	// adaption
+	success []int
+	// adaption log
+	log []AdaptRecord
+	// when not nil the log is also written to this
+	w io.Writer
+}
+
+/*
+NewAPso creates an APso with ngroups groups named "0", "1" ... where the ith
+particle is put into group i%ngroups. The groups start with copies of the
+master heuristics and are adapted using rule every window updates.
+*/
+func NewAPso(p *Pso, ngroups int, rule Adaptation, window int) *APso {
+	pso := &APso{Pso: p, rule: rule, ngroups: ngroups, Window: window}
+	pso.success = make([]int, ngroups)
+	for k := 0; k < ngroups; k++ {
+		gp := pso.CreateGroup(strconv.Itoa(k), 1)
+		pso.SetGroupHeuristics(gp, pso.hu.Clone())
+	}
+	for i := range pso.Pt {
+		pso.MoveTo(pso.Gr(strconv.Itoa(i%ngroups)), i)
+	}
+	pso.UpdateGlobal()
+	return pso
+}
+
+// SetHeuristics sets the master heuristics and resets the group heuristics to
+// copies of it.
+func (p *APso) SetHeuristics(hu *PsoHeuristics) {
+	p.hu = hu
+	for k := 0; k < p.ngroups; k++ {
+		p.SetGroupHeuristics(p.Gr(strconv.Itoa(k)), hu.Clone())
+	}
+}
+
+// SetLog sets w to be written with each record of the adaption log as it is
+// made; w = nil stops this.
+func (p *APso) SetLog(w io.Writer) { p.w = w }
+
+// AdaptLog returns the adaption log.
+func (p *APso) AdaptLog() []AdaptRecord { return p.log }
+
+// Rule returns the Adaptation in use.
+func (p *APso) Rule() Adaptation { return p.rule }
+
+// BestHeuristics returns the heuristics of the group containing the global
+// best particle.
+func (p *APso) BestHeuristics() *PsoHeuristics {
+	return p.Pt[p.BestParticle()].group.hu
+}
+
+/*
+Update sets the target of each group to the global best and does the usual
+PUpdate(). It then counts the particles that improved their Personal-best and
+adapts the group heuristics when Window updates have been done.
+*/
+func (p *APso) Update() {
+	p.ApplySchedules()
+	best := p.BestParticle()
+	for k := 0; k < p.ngroups; k++ {
+		p.SetGroupTarget(p.Gr(strconv.Itoa(k)), best)
+	}
+	p.PUpdate()
+	for i := range p.Pt {
+		if p.Pt[i].improved {
+			if k, err := strconv.Atoi(p.Pt[i].group.id); err == nil && k < p.ngroups {
+				p.success[k]++
+			}
+		}
+	}
+	p.count++
+	if p.count < p.Window {
+		return
+	}
+	for k := 0; k < p.ngroups; k++ {
+		g := p.Gr(strconv.Itoa(k))
+		if len(g.members) == 0 {
+			continue
+		}
+		rate := float64(p.success[k]) / float64(len(g.members)*p.count)
+		p.rule.Adapt(k, g.hu, rate, p.rnd)
+		rec := AdaptRecord{p.updates, k, rate,
+			append([]float64(nil), g.hu.floatValues...),
+			append([]int(nil), g.hu.intValues...)}
+		p.log = append(p.log, rec)
+		if p.w != nil {
+			fmt.Fprintf(p.w, "%d %d %f %v %v\n", rec.Update, rec.Group, rec.Success, rec.Float, rec.Int)
+		}
+		p.success[k] = 0
+	}
+	p.count = 0
+}
+
+// apsoState is the stored form of the adaption state of an APso. Rule holds the
+// state of the Adaptation when it can be marshalled.
+type apsoState struct {
+	Count   int
+	Success []int
+	Log     []AdaptRecord
+	Rule    []byte
+}
+
+// Checkpoint writes the state of the APso including its adaption counts, log
+// and the state of its Adaptation to w.
+func (p *APso) Checkpoint(w io.Writer) error {
+	st := p.state()
+	st.Adapt = &apsoState{Count: p.count, Success: p.success, Log: p.log}
+	if m, ok := p.rule.(encoding.BinaryMarshaler); ok {
+		b, err := m.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		st.Adapt.Rule = b
+	}
+	return gob.NewEncoder(w).Encode(st)
+}
+
+// Restore reads a state written by Checkpoint() from r and replaces the state
+// of the APso by it. The adaption log is not written again to the writer set by
+// SetLog().
+func (p *APso) Restore(r io.Reader) error {
+	var st psoState
+	if err := gob.NewDecoder(r).Decode(&st); err != nil {
+		return err
+	}
+	a := st.Adapt
+	if a == nil || len(a.Success) != p.ngroups {
+		return fmt.Errorf("checkpoint was not made by an APso with %d groups", p.ngroups)
+	}
+	if u, ok := p.rule.(encoding.BinaryUnmarshaler); ok && a.Rule != nil {
+		if err := u.UnmarshalBinary(a.Rule); err != nil {
+			return err
+		}
+	}
+	if err := p.setState(&st); err != nil {
+		return err
+	}
+	p.count = a.Count
+	copy(p.success, a.Success)
+	p.log = a.Log
+	return nil
+}
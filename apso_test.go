@@ -0,0 +1,55 @@
+package setpso_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+)
+
+func TestAPso(t *testing.T) {
+	rules := []setpso.Adaptation{
+		&setpso.OneFifthRule{Ranges: []setpso.HeuristicRange{
+			{Index: setpso.LfactorHeuristic, Low: 0.05, High: 0.3}},
+			Factor: 1.5, Target: 0.2},
+		&setpso.BanditRule{Indexes: []int{setpso.OmegaHeuristic},
+			Arms: [][]float64{{0.6}, {0.73}, {0.85}}, C: 0.1},
+	}
+	for _, rule := range rules {
+		pso := setpso.NewAPso(newPso(12), 3, rule, 5)
+		var buf bytes.Buffer
+		pso.SetLog(&buf)
+		for i := 0; i < 50; i++ {
+			pso.Update()
+		}
+		log := pso.AdaptLog()
+		if len(log) != 30 {
+			t.Fatalf("%s: adaption log has %d records want 30", rule.About(), len(log))
+		}
+		if bytes.Count(buf.Bytes(), []byte("\n")) != len(log) {
+			t.Errorf("%s: written log does not match the adaption log", rule.About())
+		}
+		for _, rec := range log {
+			if rec.Success < 0.0 || rec.Success > 1.0 {
+				t.Errorf("%s: success rate %f out of range", rule.About(), rec.Success)
+			}
+			switch r := rule.(type) {
+			case *setpso.OneFifthRule:
+				if l := rec.Float[setpso.LfactorHeuristic]; l < 0.05 || l > 0.3 {
+					t.Errorf("adapted lfactor %f out of bounds", l)
+				}
+			case *setpso.BanditRule:
+				found := false
+				for _, arm := range r.Arms {
+					found = found || rec.Float[setpso.OmegaHeuristic] == arm[0]
+				}
+				if !found {
+					t.Errorf("adapted omega %f is not an arm", rec.Float[setpso.OmegaHeuristic])
+				}
+			}
+		}
+		if pso.BestHeuristics() == pso.Heuristics() {
+			t.Errorf("%s: best group uses the master heuristics", rule.About())
+		}
+	}
+}
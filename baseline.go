@@ -0,0 +1,214 @@
+package setpso
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+/*
+BPso is the classic binary PSO of Kennedy and Eberhart, given as a baseline to
+compare the SPSOs with. Each particle's velocity is a real value for each bit,
+kept in the particle's velocity, which is moved toward the Personal-best and the
+global best by
+
+	v = W*v + C1*r1*(pbest-x) + C2*r2*(gbest-x)
+
+with r1 and r2 uniformly random in [0,1), and is clamped to [-Vmax,Vmax]. Each
+bit of the new Parameters is then set to 1 with probability sigmoid(v). The
+heuristics of the swarm, apart from ThresholdHeuristic and NTriesHeuristic, are
+not used.
+*/
+type BPso struct {
+	*Pso
+	W, C1, C2, Vmax float64
+	// scratch pad words for the new Parameters
+	words []big.Word
+}
+
+// NewBPso creates a BPso with W = 1, C1 = C2 = 2 and Vmax = 4. The velocities
+// are switched to the dense form.
+func NewBPso(p *Pso) *BPso {
+	p.SetSparseVelocity(0)
+	return &BPso{Pso: p, W: 1, C1: 2, C2: 2, Vmax: 4,
+		words: make([]big.Word, len(p.flip))}
+}
+
+//SetHeuristics sets the heuristics for the particle swarm.
+func (p *BPso) SetHeuristics(hu *PsoHeuristics) { p.hu = hu }
+
+// Update moves the velocities toward the Personal-best and global best and
+// samples new Parameters from them.
+func (p *BPso) Update() {
+	p.ApplySchedules()
+	g := p.Pt[p.bestParticle].bestTry.Parameter()
+	for k := range p.Pt {
+		pt := &p.Pt[k]
+		x := pt.current.Parameter()
+		b := pt.bestTry.Parameter()
+		for i := range p.words {
+			p.words[i] = 0
+		}
+		for j := range pt.vel {
+			xj := float64(x.Bit(j))
+			v := p.W*pt.vel[j] + p.C1*p.rnd.Float64()*(float64(b.Bit(j))-xj) +
+				p.C2*p.rnd.Float64()*(float64(g.Bit(j))-xj)
+			v = math.Min(math.Max(v, -p.Vmax), p.Vmax)
+			pt.vel[j] = v
+			if p.rnd.Float64() < 1/(1+math.Exp(-v)) {
+				setWordBit(p.words, j)
+			}
+		}
+		pt.hint.Set(p.flipMask.SetBits(p.words))
+	}
+	p.setAllParams()
+	p.endUpdate()
+}
+
+/*
+GA is a steady state genetic algorithm, given as a baseline to compare the
+SPSOs with, whose population is the Personal-bests of the particles. Each
+update makes one child for each particle from two parents each chosen by a
+binary tournament. With probability Pc the child takes each bit from either
+parent with equal chance, uniform crossover, otherwise it is a copy of the
+first parent; each bit is then flipped with probability Pm. A child that
+satisfies the constraints and is better than the worst Personal-best replaces
+it, becoming the current try of that particle. The costs are evaluated
+serially and the heuristics of the swarm are not used.
+*/
+type GA struct {
+	*Pso
+	Pc, Pm float64
+	// scratch pads for the child and the crossover mask
+	child Try
+	mask  *big.Int
+}
+
+// NewGA creates a GA with Pc = 0.9 and Pm = 1/MaxLen().
+func NewGA(p *Pso) *GA {
+	return &GA{Pso: p, Pc: 0.9, Pm: 1 / float64(p.maxLen),
+		child: p.fun.NewTry(), mask: new(big.Int)}
+}
+
+//SetHeuristics sets the heuristics for the particle swarm.
+func (p *GA) SetHeuristics(hu *PsoHeuristics) { p.hu = hu }
+
+// tournament returns the better of two particles chosen at random.
+func (p *GA) tournament() int {
+	i := p.rnd.Intn(len(p.Pt))
+	j := p.rnd.Intn(len(p.Pt))
+	if p.fun.Cmp(p.Pt[i].bestTry, p.Pt[j].bestTry, futil.CostMode) > 0.0 {
+		return j
+	}
+	return i
+}
+
+// worst returns the particle with the worst Personal-best.
+func (p *GA) worst() int {
+	w := 0
+	for i := range p.Pt {
+		if p.fun.Cmp(p.Pt[w].bestTry, p.Pt[i].bestTry, futil.CostMode) < 0.0 {
+			w = i
+		}
+	}
+	return w
+}
+
+// Update makes and inserts one child for each particle.
+func (p *GA) Update() {
+	p.ApplySchedules()
+	for i := range p.Pt {
+		p.Pt[i].improved = false
+		p.fun.UpdateCost(p.Pt[i].bestTry)
+	}
+	for range p.Pt {
+		a := p.Pt[p.tournament()].bestTry
+		b := p.Pt[p.tournament()].bestTry
+		p.temp.Set(a.Parameter())
+		if p.rnd.Float64() < p.Pc {
+			p.mask.Rand(p.rnd, p.maxN)
+			p.temp.AndNot(p.temp, p.mask)
+			p.mask.And(p.mask, b.Parameter())
+			p.temp.Or(p.temp, p.mask)
+		}
+		for j := 0; j < p.maxLen; j++ {
+			if p.rnd.Float64() < p.Pm {
+				p.temp.SetBit(p.temp, j, p.temp.Bit(j)^1)
+			}
+		}
+		p.fun.Copy(p.child, a)
+		if !p.fun.ToConstraint(p.child, p.temp) {
+			continue
+		}
+		w := &p.Pt[p.worst()]
+		if p.fun.Cmp(w.bestTry, p.child, futil.CostMode) > 0.0 {
+			p.fun.Copy(w.bestTry, p.child)
+			p.fun.Copy(w.current, p.child)
+			w.tries = w.tries[:0]
+			w.improved = true
+		}
+	}
+	p.endUpdate()
+}
+
+/*
+SA is simulated annealing, given as a baseline to compare the SPSOs with, where
+each particle runs its own Markov chain from its current try. Each update every
+chain proposes flipping Flips random bits, which is accepted if it satisfies
+the constraints and either does not increase Fbits() or, if it increases it by
+d, with probability exp(-d/T). The temperature is T = T0*Alpha^n for the update
+after n updates. The Personal-best keeps the best try of the chain. The costs are
+evaluated serially and the heuristics of the swarm are not used.
+*/
+type SA struct {
+	*Pso
+	T0, Alpha float64
+	Flips     int
+	// scratch pad for the proposal
+	next Try
+}
+
+// NewSA creates a SA with T0 = 1, Alpha = 0.99 and Flips = 1.
+func NewSA(p *Pso) *SA {
+	return &SA{Pso: p, T0: 1, Alpha: 0.99, Flips: 1, next: p.fun.NewTry()}
+}
+
+//SetHeuristics sets the heuristics for the particle swarm.
+func (p *SA) SetHeuristics(hu *PsoHeuristics) { p.hu = hu }
+
+// Temperature returns the temperature used by the next update.
+func (p *SA) Temperature() float64 {
+	return p.T0 * math.Pow(p.Alpha, float64(p.updates))
+}
+
+// Update makes one move of each chain.
+func (p *SA) Update() {
+	temp := p.Temperature()
+	p.ApplySchedules()
+	for k := range p.Pt {
+		pt := &p.Pt[k]
+		pt.improved = false
+		p.fun.UpdateCost(pt.bestTry)
+		p.fun.UpdateCost(pt.current)
+		pt.hint.Set(pt.current.Parameter())
+		for i := 0; i < p.Flips; i++ {
+			j := p.rnd.Intn(p.maxLen)
+			pt.hint.SetBit(pt.hint, j, pt.hint.Bit(j)^1)
+		}
+		p.fun.Copy(p.next, pt.current)
+		if !p.fun.ToConstraint(p.next, pt.hint) {
+			continue
+		}
+		d := p.next.Fbits() - pt.current.Fbits()
+		if d > 0 && p.rnd.Float64() >= math.Exp(-d/temp) {
+			continue
+		}
+		p.fun.Copy(pt.current, p.next)
+		if p.fun.Cmp(pt.bestTry, pt.current, futil.CostMode) > 0.0 {
+			p.fun.Copy(pt.bestTry, pt.current)
+			pt.improved = true
+		}
+	}
+	p.endUpdate()
+}
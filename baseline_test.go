@@ -0,0 +1,46 @@
+package setpso_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/futil"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+func TestBaselines(t *testing.T) {
+	f := subsetsum.New(100, 20, 3142)
+	cases := []struct {
+		name string
+		pso  setpso.PsoInterface
+	}{
+		{"bpso", setpso.NewBPso(newPso(20))},
+		{"ga", setpso.NewGA(newPso(20))},
+		{"sa", setpso.NewSA(newPso(20))},
+	}
+	for _, c := range cases {
+		start := f.NewTry()
+		f.Copy(start, c.pso.LocalBestTry(c.pso.BestParticle()))
+		for i := 0; i < 200; i++ {
+			c.pso.Update()
+		}
+		if gb := c.pso.LocalBestTry(c.pso.BestParticle()); f.Cmp(gb, start, futil.CostMode) >= 0.0 {
+			t.Errorf("%s: no improvement from cost %s", c.name, start.Cost())
+		}
+	}
+	sa := setpso.NewSA(setpso.NewPso(5, f, 1))
+	sa.Update()
+	if got, want := sa.Temperature(), sa.T0*sa.Alpha; math.Abs(got-want) > 1e-12 {
+		t.Errorf("temperature after one update is %f, want %f", got, want)
+	}
+	// each particle samples its own Parameters
+	bpso := setpso.NewBPso(newPso(20))
+	bpso.Update()
+	for i := 1; i < bpso.Nparticles(); i++ {
+		if bpso.CurrentTry(i).Parameter().Cmp(bpso.CurrentTry(0).Parameter()) != 0 {
+			return
+		}
+	}
+	t.Errorf("bpso particles share one current Parameter after an update")
+}
@@ -0,0 +1,29 @@
+package setpso
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// wordBits is the number of bits in a big.Word.
+const wordBits = bits.UintSize
+
+/*
+ForEachBit calls fn(i) for each bit i that is 1 in the non-negative x in
+increasing order of i. It works a word at a time so skips quickly over zero
+bits.
+*/
+func ForEachBit(x *big.Int, fn func(i int)) {
+	for k, w := range x.Bits() {
+		for w != 0 {
+			j := bits.TrailingZeros(uint(w))
+			fn(k*wordBits + j)
+			w &= w - 1
+		}
+	}
+}
+
+// setWordBit sets bit i of the words ws to 1.
+func setWordBit(ws []big.Word, i int) {
+	ws[i/wordBits] |= 1 << uint(i%wordBits)
+}
@@ -0,0 +1,78 @@
+package setpso_test
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+// randomBits returns a random n bit number with about one bit in every
+// sparsity set.
+func randomBits(n, sparsity int, sd int64) *big.Int {
+	rnd := rand.New(rand.NewSource(sd))
+	x := new(big.Int)
+	for i := 0; i < n; i++ {
+		if rnd.Intn(sparsity) == 0 {
+			x.SetBit(x, i, 1)
+		}
+	}
+	return x
+}
+
+func TestForEachBit(t *testing.T) {
+	x := randomBits(1000, 3, 42)
+	var got []int
+	setpso.ForEachBit(x, func(i int) { got = append(got, i) })
+	k := 0
+	for i := 0; i < x.BitLen(); i++ {
+		if x.Bit(i) == 1 {
+			if k >= len(got) || got[k] != i {
+				t.Fatalf("bit %d missing from ForEachBit", i)
+			}
+			k++
+		}
+	}
+	if k != len(got) || setpso.CardinalSize(x) != k {
+		t.Errorf("ForEachBit gave %d bits and CardinalSize %d want %d", len(got), setpso.CardinalSize(x), k)
+	}
+}
+
+func BenchmarkCardinalSize(b *testing.B) {
+	x := randomBits(4096, 2, 42)
+	for i := 0; i < b.N; i++ {
+		setpso.CardinalSize(x)
+	}
+}
+
+// BenchmarkCardinalSizeBitwise counts bits one at a time for comparison with
+// BenchmarkCardinalSize.
+func BenchmarkCardinalSizeBitwise(b *testing.B) {
+	x := randomBits(4096, 2, 42)
+	for i := 0; i < b.N; i++ {
+		card := 0
+		for j := 0; j < x.BitLen(); j++ {
+			if x.Bit(j) == 1 {
+				card++
+			}
+		}
+	}
+}
+
+func BenchmarkForEachBit(b *testing.B) {
+	x := randomBits(4096, 50, 42)
+	for i := 0; i < b.N; i++ {
+		n := 0
+		setpso.ForEachBit(x, func(j int) { n += j })
+	}
+}
+
+func BenchmarkGPsoUpdate(b *testing.B) {
+	pso := setpso.NewGPso(setpso.NewPso(20, subsetsum.New(4096, 20, 3142), 578))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pso.Update()
+	}
+}
@@ -0,0 +1,181 @@
+package setpso
+
+import (
+	"container/list"
+	"fmt"
+	"math/big"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+/*
+CachingFun wraps a deterministic cost-function with a bounded least recently
+used cache of tries by parameter. SetTry() and UpdateCost() copy the cached try
+when the parameter is in the cache so the parameter is not decoded and costed
+again, which is most useful for the Personal-bests that are re-costed every
+update. ToConstraint() can change the parameter so it always calls the
+cost-function but its result is cached. A successful Delete() changes the
+meaning of the parameters so the cache is cleared.
+
+Like cost-functions a CachingFun is not safe for concurrent use so each worker
+set up with SetWorkers() should have its own.
+*/
+type CachingFun struct {
+	Fun
+	// maximum number of cached tries
+	size int
+	// cache entries by parameter key
+	entries map[string]*list.Element
+	// entries in order of use with the most recent at the front
+	lru *list.List
+	// scratch pad for parameter keys
+	buf []byte
+	// cache lookup counts
+	hits, misses int64
+}
+
+// cacheEntry is a cached try with its key.
+type cacheEntry struct {
+	key string
+	try Try
+}
+
+/*
+NewCachingFun returns f wrapped with a cache of up to size tries. It returns an
+error for cost-functions with noisy costs, such as those using
+futil.SFloatFunStub or futil.VFloatFunStub, since caching would stop their costs
+being re-sampled.
+*/
+func NewCachingFun(f Fun, size int) (*CachingFun, error) {
+	if isNoisy(f) {
+		return nil, fmt.Errorf("cost-function %T has noisy costs so cannot be cached", f)
+	}
+	if size < 1 {
+		return nil, fmt.Errorf("cache size %d is less than 1", size)
+	}
+	c := new(CachingFun)
+	c.Fun = f
+	c.size = size
+	c.entries = make(map[string]*list.Element)
+	c.lru = list.New()
+	return c, nil
+}
+
+// isNoisy returns true if f, or the cost-function it wraps, has noisy costs.
+func isNoisy(f Fun) bool {
+	for {
+		switch g := f.(type) {
+		case *futil.SFloatFunStub, *futil.VFloatFunStub:
+			return true
+		case *CountingFun:
+			f = g.Fun
+		case *CachingFun:
+			f = g.Fun
+		default:
+			return false
+		}
+	}
+}
+
+// key returns the cache key of the parameter z.
+func (c *CachingFun) key(z *big.Int) string {
+	c.buf = z.Append(c.buf[:0], 16)
+	return string(c.buf)
+}
+
+// lookup copies the cached try with parameter z to t and returns true if there
+// is one.
+func (c *CachingFun) lookup(t Try, z *big.Int) bool {
+	e, ok := c.entries[c.key(z)]
+	if !ok {
+		c.misses++
+		return false
+	}
+	c.hits++
+	c.lru.MoveToFront(e)
+	c.Fun.Copy(t, e.Value.(*cacheEntry).try)
+	return true
+}
+
+// store puts a copy of t into the cache reusing the least recently used entry
+// when the cache is full.
+func (c *CachingFun) store(t Try) {
+	k := c.key(t.Parameter())
+	if e, ok := c.entries[k]; ok {
+		c.lru.MoveToFront(e)
+		c.Fun.Copy(e.Value.(*cacheEntry).try, t)
+		return
+	}
+	var ce *cacheEntry
+	if c.lru.Len() >= c.size {
+		e := c.lru.Back()
+		ce = e.Value.(*cacheEntry)
+		delete(c.entries, ce.key)
+		c.lru.Remove(e)
+	} else {
+		ce = &cacheEntry{try: c.Fun.NewTry()}
+	}
+	ce.key = k
+	c.Fun.Copy(ce.try, t)
+	c.entries[k] = c.lru.PushFront(ce)
+}
+
+// SetTry sets t to the parameter z using the cache when possible.
+func (c *CachingFun) SetTry(t Try, z *big.Int) {
+	if c.lookup(t, z) {
+		return
+	}
+	c.Fun.SetTry(t, z)
+	c.store(t)
+}
+
+// UpdateCost updates the cost of x using the cache when possible.
+func (c *CachingFun) UpdateCost(x Try) {
+	if c.lookup(x, x.Parameter()) {
+		return
+	}
+	c.Fun.UpdateCost(x)
+	c.store(x)
+}
+
+// ToConstraint calls the cost-function's ToConstraint() and caches pre on
+// success.
+func (c *CachingFun) ToConstraint(pre Try, hint *big.Int) bool {
+	if !c.Fun.ToConstraint(pre, hint) {
+		return false
+	}
+	c.store(pre)
+	return true
+}
+
+// Delete calls the cost-function's Delete() and clears the cache if the item
+// is replaced.
+func (c *CachingFun) Delete(i int) bool {
+	if !c.Fun.Delete(i) {
+		return false
+	}
+	c.Clear()
+	return true
+}
+
+// Clear empties the cache.
+func (c *CachingFun) Clear() {
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// Len returns the number of cached tries.
+func (c *CachingFun) Len() int { return c.lru.Len() }
+
+// Lookups returns the number of SetTry() and UpdateCost() calls that found
+// their parameter in the cache and the number that did not.
+func (c *CachingFun) Lookups() (hits, misses int64) { return c.hits, c.misses }
+
+// HitRate returns the fraction of lookups that found their parameter in the
+// cache or 0 if there have been none.
+func (c *CachingFun) HitRate() float64 {
+	if c.hits+c.misses == 0 {
+		return 0.0
+	}
+	return float64(c.hits) / float64(c.hits+c.misses)
+}
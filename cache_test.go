@@ -0,0 +1,54 @@
+package setpso_test
+
+import (
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/multimode"
+	"github.com/mathrgo/setpso/fun/poolsum"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+func TestCachingFun(t *testing.T) {
+	run := func(f setpso.Fun) *setpso.GPso {
+		pso := setpso.NewGPso(setpso.NewPso(20, f, 578))
+		for i := 0; i < 200; i++ {
+			pso.Update()
+		}
+		return pso
+	}
+	plain := run(subsetsum.New(100, 20, 3142))
+	c, err := setpso.NewCachingFun(subsetsum.New(100, 20, 3142), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached := run(c)
+	// the cache does not change the run
+	samePersonalBests(t, plain, cached)
+	if c.Len() > 50 {
+		t.Errorf("cache holds %d tries but its size is 50", c.Len())
+	}
+	// the Personal-bests are re-costed every update so are found in the cache
+	if c.HitRate() < 0.3 {
+		t.Errorf("hit rate %f is too low", c.HitRate())
+	}
+
+	// replacing items clears the cache
+	runReplace := func(f setpso.Fun) *setpso.GPso {
+		pso := setpso.NewGPso(setpso.NewPso(10, f, 578))
+		pso.SetItemReplacement(20, 0.05)
+		for i := 0; i < 200; i++ {
+			pso.Update()
+		}
+		return pso
+	}
+	plain = runReplace(poolsum.New(30, 90, 20, 3142))
+	c, _ = setpso.NewCachingFun(poolsum.New(30, 90, 20, 3142), 50)
+	samePersonalBests(t, plain, runReplace(c))
+
+	// noisy cost-functions are refused even when wrapped
+	noisy := multimode.NewFun(5, 10, 0.1, 0.1, 5, 2, 3142)
+	if _, err := setpso.NewCachingFun(setpso.NewCountingFun(noisy, nil), 50); err == nil {
+		t.Errorf("noisy cost-function was cached")
+	}
+}
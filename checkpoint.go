@@ -0,0 +1,495 @@
+package setpso
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
+	"os"
+)
+
+/*
+Checkpointer is the interface for SPSOs that can save their full state so that
+a long run can be restored later and continue exactly where it stopped. Pso,
+GPso, CLPso, LPso, FIPso, NPso, APso and MOPso all support this interface, as do the
+baselines BPso, GA, SA and PBIL. Islands and CCPso support it when their
+swarms do.
+
+Only the state of the SPSO is saved, not that of the cost-function, so a
+restored run continues exactly only for deterministic cost-functions without
+state of their own. Noisy costs, such as those of SFloatFunStub and
+VFloatFunStub, restart their sample statistics from a single evaluation, and
+the random generators of noisy cost-functions, such as those of the multimode
+and dag packages, or items deleted by SetItemReplacement(), are not restored.
+*/
+type Checkpointer interface {
+	// Checkpoint writes the state to w.
+	Checkpoint(w io.Writer) error
+	// Restore reads back a state written by Checkpoint() from r.
+	Restore(r io.Reader) error
+}
+
+/*
+countedSource is the random number source used by Pso. It gives the same values
+as the standard source with the same seed but keeps its state in a form that
+can be saved, so the position of the random generator is restored exactly
+without replaying the draws. The standard source is an additive lagged
+Fibonacci generator in which each value is the sum of the values drawn rngLen
+and rngTap draws before, so once the first rngLen values have been taken from
+the standard source the last rngLen values drawn are its whole state.
+*/
+type countedSource struct {
+	// the last rngLen values drawn in a ring starting at pos; until rngLen
+	// values have been drawn it holds the first rngLen values
+	vec   [rngLen]uint64
+	pos   int
+	seed  int64
+	count uint64
+}
+
+// rngLen and rngTap are the lags of the standard source.
+const (
+	rngLen = 607
+	rngTap = 273
+)
+
+func newCountedSource(sd int64) *countedSource {
+	s := new(countedSource)
+	s.Seed(sd)
+	return s
+}
+
+// Seed restarts the source with seed sd.
+func (s *countedSource) Seed(sd int64) {
+	src := rand.NewSource(sd).(rand.Source64)
+	for i := range s.vec {
+		s.vec[i] = src.Uint64()
+	}
+	s.pos = 0
+	s.seed = sd
+	s.count = 0
+}
+
+// Int63 returns the next random value as a non negative int64.
+func (s *countedSource) Int63() int64 {
+	return int64(s.Uint64() & (1<<63 - 1))
+}
+
+// Uint64 returns the next random value as a uint64.
+func (s *countedSource) Uint64() uint64 {
+	var x uint64
+	if s.count < rngLen {
+		x = s.vec[s.count]
+	} else {
+		t := s.pos + rngLen - rngTap
+		if t >= rngLen {
+			t -= rngLen
+		}
+		x = s.vec[s.pos] + s.vec[t]
+		s.vec[s.pos] = x
+		s.pos++
+		if s.pos == rngLen {
+			s.pos = 0
+		}
+	}
+	s.count++
+	return x
+}
+
+// state returns the values that make up the state of the source, oldest
+// first.
+func (s *countedSource) state() []uint64 {
+	return append(append([]uint64(nil), s.vec[s.pos:]...), s.vec[:s.pos]...)
+}
+
+/*
+setState restarts the source with seed sd at the position count draws on with
+the state vec given by state(). Without a state, as in checkpoints made before
+the state was stored, it replays count draws from the seed.
+*/
+func (s *countedSource) setState(sd int64, count uint64, vec []uint64) error {
+	switch len(vec) {
+	case 0:
+		s.Seed(sd)
+		for s.count < count {
+			s.Uint64()
+		}
+	case rngLen:
+		copy(s.vec[:], vec)
+		s.pos = 0
+		s.seed = sd
+		s.count = count
+	default:
+		return fmt.Errorf("random generator state has %d values, want %d", len(vec), rngLen)
+	}
+	return nil
+}
+
+// heuristicsState is the stored form of PsoHeuristics.
+type heuristicsState struct {
+	Float []float64
+	Int   []int
+}
+
+// groupState is the stored form of a Group. Heuristics indexes into the
+// list of stored heuristics so that sharing of heuristics is kept.
+type groupState struct {
+	Name       string
+	Members    []int
+	Targets    []int
+	Weights    []float64
+	BestMember int
+	Heuristics int
+}
+
+// particleState is the stored form of a Particle. Sparse replaces Vel for
+// sparse velocities.
+type particleState struct {
+	Current *big.Int
+	Best    *big.Int
+	Tries   []*big.Int
+	Vel     []float64
+	Sparse  *sparseState
+	Group   string
+}
+
+// sparseState is the stored form of a sparseVel.
+type sparseState struct {
+	Idx   []int
+	U     []float64
+	Scale float64
+	Bg    float64
+}
+
+// clPartState is the stored form of a CLpart.
+type clPartState struct {
+	Pc       float64
+	LastBest *big.Int
+	GapCount int
+	Winners  []int
+	Masks    []*big.Int
+}
+
+/*
+psoState is the stored form of a swarm. RandState holds the state of the
+random generator. The first entry of Heuristics is the master heuristics. Updates is the update count used by heuristic schedules,
+which are not stored themselves. CL and TryGap are only used by CLPso; Iter and Neighbours
+are only used by LPso. The Restart fields and Archive hold the state of the
+restart policy, which is not stored itself. Elite holds the Parameters of the
+elite archive. The Local fields hold the state of the local search, which is
+not stored itself. Front holds the Parameters of the archive of a MOPso and
+Model holds the probability model of a PBIL and Adapt the adaption state of an
+APso. The Race fields hold the state of
+the racing of noisy tries, whose racer is not stored itself.
+*/
+type psoState struct {
+	MaxLen        int
+	Seed          int64
+	RandCount     uint64
+	RandState     []uint64
+	Heuristics    []heuristicsState
+	Groups        []groupState
+	Particles     []particleState
+	BestParticle  int
+	Updates       int
+	RestartAge    int
+	Restarts      int
+	RestartBest   *big.Int
+	Archive       []*big.Int
+	Elite         []*big.Int
+	LocalIter     int
+	LocalImproved int
+	LocalEvals    [3]int64
+	RaceBest      []SampleStats
+	RaceBestX     []*big.Int
+	RaceEvals     int64
+	RaceWins      int
+	CL            []clPartState
+	TryGap        int
+	Iter          int
+	Neighbours    [][]int
+	Front         []*big.Int
+	Model         []float64
+	Adapt         *apsoState
+}
+
+// state returns the stored form of the Pso state.
+func (pso *Pso) state() *psoState {
+	st := new(psoState)
+	st.MaxLen = pso.maxLen
+	st.Seed = pso.src.seed
+	st.RandCount = pso.src.count
+	st.RandState = pso.src.state()
+	st.BestParticle = pso.bestParticle
+	st.Updates = pso.updates
+	if pso.elite != nil {
+		st.Elite = pso.elite.params()
+	}
+	if r := pso.restart; r != nil {
+		st.RestartAge = r.age
+		st.Restarts = r.nrestarts
+		st.RestartBest = new(big.Int).Set(r.best.Parameter())
+		st.Archive = r.archive.params()
+	}
+	if l := pso.local; l != nil {
+		st.LocalIter = l.iter
+		st.LocalImproved = l.nimproved
+		st.LocalEvals[0], st.LocalEvals[1], st.LocalEvals[2] = l.f.Counts()
+	}
+	if r := pso.racing; r != nil {
+		st.RaceBest = r.best
+		st.RaceBestX = r.bestX
+		st.RaceEvals = r.evals
+		st.RaceWins = r.nwins
+	}
+	huIndex := make(map[*PsoHeuristics]int)
+	addHeuristics := func(hu *PsoHeuristics) int {
+		if k, ok := huIndex[hu]; ok {
+			return k
+		}
+		k := len(st.Heuristics)
+		huIndex[hu] = k
+		st.Heuristics = append(st.Heuristics, heuristicsState{
+			append([]float64(nil), hu.floatValues...),
+			append([]int(nil), hu.intValues...)})
+		return k
+	}
+	addHeuristics(pso.hu)
+	for name, g := range pso.gr {
+		st.Groups = append(st.Groups, groupState{
+			Name:       name,
+			Members:    append([]int(nil), g.members...),
+			Targets:    append([]int(nil), g.targets...),
+			Weights:    append([]float64(nil), g.weights...),
+			BestMember: g.bestMember,
+			Heuristics: addHeuristics(g.hu)})
+	}
+	st.Particles = make([]particleState, len(pso.Pt))
+	for i := range pso.Pt {
+		p := &pso.Pt[i]
+		ps := &st.Particles[i]
+		ps.Current = new(big.Int).Set(p.current.Parameter())
+		ps.Best = new(big.Int).Set(p.bestTry.Parameter())
+		ps.Tries = make([]*big.Int, len(p.tries))
+		for j := range p.tries {
+			ps.Tries[j] = new(big.Int).Set(p.tries[j].Parameter())
+		}
+		if p.sv != nil {
+			ps.Sparse = p.sv.state()
+		} else {
+			ps.Vel = append([]float64(nil), p.vel...)
+		}
+		ps.Group = p.group.id
+	}
+	return st
+}
+
+/*
+setState replaces the Pso state by st. The tries are rebuilt from their
+parameters using the cost function so costs are re-evaluated.
+*/
+func (pso *Pso) setState(st *psoState) error {
+	if len(st.Particles) != len(pso.Pt) {
+		return fmt.Errorf("checkpoint has %d particles but the swarm has %d",
+			len(st.Particles), len(pso.Pt))
+	}
+	if st.MaxLen != pso.maxLen {
+		return fmt.Errorf("checkpoint has parameters of %d bits but the cost-function uses %d",
+			st.MaxLen, pso.maxLen)
+	}
+	if len(st.Heuristics) == 0 {
+		return fmt.Errorf("checkpoint has no heuristics")
+	}
+	hus := make([]*PsoHeuristics, len(st.Heuristics))
+	for k := range st.Heuristics {
+		// start from defaults so heuristics added since the checkpoint are set
+		hu := pso.CreatePsoHeuristics()
+		copy(hu.floatValues, st.Heuristics[k].Float)
+		copy(hu.intValues, st.Heuristics[k].Int)
+		hus[k] = hu
+	}
+	pso.hu = hus[0]
+	// groups are rebuilt but keep their configured combiner
+	gr0 := pso.gr
+	pso.gr = make(map[string]*Group, len(st.Groups))
+	for _, gs := range st.Groups {
+		if gs.Heuristics < 0 || gs.Heuristics >= len(hus) {
+			return fmt.Errorf("group %s has unknown heuristics %d", gs.Name, gs.Heuristics)
+		}
+		g := new(Group)
+		g.id = gs.Name
+		g.members = append([]int(nil), gs.Members...)
+		g.targets = append([]int(nil), gs.Targets...)
+		g.weights = append([]float64(nil), gs.Weights...)
+		g.bestMember = gs.BestMember
+		g.hu = hus[gs.Heuristics]
+		if g0 := gr0[gs.Name]; g0 != nil {
+			g.comb = g0.comb
+		}
+		pso.gr[gs.Name] = g
+	}
+	for i := range pso.Pt {
+		p := &pso.Pt[i]
+		ps := &st.Particles[i]
+		g := pso.gr[ps.Group]
+		if g == nil {
+			return fmt.Errorf("particle %d belongs to unknown group %s", i, ps.Group)
+		}
+		if ps.Sparse == nil && len(ps.Vel) != pso.maxLen {
+			return fmt.Errorf("particle %d has velocity of length %d", i, len(ps.Vel))
+		}
+		if ps.Sparse != nil && !ps.Sparse.valid(pso.maxLen) {
+			return fmt.Errorf("particle %d has an invalid sparse velocity", i)
+		}
+		p.group = g
+		pso.fun.SetTry(p.current, ps.Current)
+		pso.fun.SetTry(p.bestTry, ps.Best)
+		p.tries = p.tries[:0]
+		for j := range ps.Tries {
+			try := pso.fun.NewTry()
+			pso.fun.SetTry(try, ps.Tries[j])
+			p.tries = append(p.tries, try)
+		}
+		if ps.Sparse != nil {
+			p.setSparseState(ps.Sparse, pso.maxLen)
+		} else {
+			p.setVelocity(ps.Vel, pso.eps)
+		}
+	}
+	pso.bestParticle = st.BestParticle
+	pso.updates = st.Updates
+	if pso.elite != nil {
+		pso.elite.setParams(pso.fun, st.Elite)
+	}
+	if r := pso.restart; r != nil && st.RestartBest != nil {
+		r.age = st.RestartAge
+		r.nrestarts = st.Restarts
+		pso.fun.SetTry(r.best, st.RestartBest)
+		r.archive.setParams(pso.fun, st.Archive)
+	}
+	if l := pso.local; l != nil {
+		l.iter = st.LocalIter
+		l.nimproved = st.LocalImproved
+		l.f.Set(st.LocalEvals[0], st.LocalEvals[1], st.LocalEvals[2])
+	}
+	if r := pso.racing; r != nil && len(st.RaceBest) == len(r.best) {
+		copy(r.best, st.RaceBest)
+		for i := range r.bestX {
+			r.bestX[i].Set(st.RaceBestX[i])
+		}
+		r.evals = st.RaceEvals
+		r.nwins = st.RaceWins
+	}
+	return pso.src.setState(st.Seed, st.RandCount, st.RandState)
+}
+
+/*
+Checkpoint writes the state of the swarm to w. This covers each Particle's
+Parameters, Personal-best Parameters, Velocity and list of tries, the Groups
+with their Targets and Heuristics and the position of the random number
+generator. Tries are stored by their Parameters so on restoring costs are
+re-evaluated by the cost-function; this is exact for deterministic costs.
+*/
+func (pso *Pso) Checkpoint(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(pso.state())
+}
+
+/*
+Restore reads a state written by Checkpoint() from r and replaces the state of
+the swarm by it. The swarm should be created using NewPso() with the same
+number of particles and cost-function as the one that was saved. The random
+generator is restored exactly from its saved state. See Checkpointer for the
+cost-function state that is not restored.
+*/
+func (pso *Pso) Restore(r io.Reader) error {
+	var st psoState
+	if err := gob.NewDecoder(r).Decode(&st); err != nil {
+		return err
+	}
+	return pso.setState(&st)
+}
+
+// Checkpoint writes the state of the CLPso including its particle gap
+// counters and exemplar winners to w.
+func (p *CLPso) Checkpoint(w io.Writer) error {
+	st := p.state()
+	st.TryGap = p.TryGap
+	st.CL = make([]clPartState, len(p.clPt))
+	for i := range p.clPt {
+		c := &p.clPt[i]
+		cs := clPartState{Pc: c.pc, LastBest: new(big.Int).Set(c.lastBest.Parameter()), GapCount: c.gapCount}
+		if c.exemplar != nil {
+			cs.Winners = append([]int(nil), c.winners...)
+			for _, m := range c.masks {
+				cs.Masks = append(cs.Masks, new(big.Int).Set(m))
+			}
+		}
+		st.CL[i] = cs
+	}
+	return gob.NewEncoder(w).Encode(st)
+}
+
+// Restore reads a state written by Checkpoint() from r and replaces the state
+// of the CLPso by it.
+func (p *CLPso) Restore(r io.Reader) error {
+	var st psoState
+	if err := gob.NewDecoder(r).Decode(&st); err != nil {
+		return err
+	}
+	if len(st.CL) != len(p.clPt) {
+		return fmt.Errorf("checkpoint was not made by a CLPso with %d particles", len(p.clPt))
+	}
+	for i := range st.CL {
+		if len(st.CL[i].Masks) != len(p.clPt[i].masks) || len(st.CL[i].Winners) != len(p.clPt[i].winners) {
+			return fmt.Errorf("checkpoint was not made by a CLPso with %d winners", p.Winners)
+		}
+	}
+	if err := p.setState(&st); err != nil {
+		return err
+	}
+	p.TryGap = st.TryGap
+	for i := range p.clPt {
+		c := &p.clPt[i]
+		cs := &st.CL[i]
+		c.pc = cs.Pc
+		p.fun.SetTry(c.lastBest, cs.LastBest)
+		c.gapCount = cs.GapCount
+		copy(c.winners, cs.Winners)
+		for k, m := range cs.Masks {
+			c.masks[k].Set(m)
+		}
+	}
+	return nil
+}
+
+/*
+CheckpointFile saves the state of c to the file filename. The state is first
+written to a temporary file which then replaces filename so that a crash while
+saving does not lose the previous checkpoint.
+*/
+func CheckpointFile(c Checkpointer, filename string) error {
+	tmp := filename + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err = c.Checkpoint(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// RestoreFile restores the state of c from the file filename.
+func RestoreFile(c Checkpointer, filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return c.Restore(file)
+}
@@ -0,0 +1,109 @@
+package setpso_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+// samePersonalBests reports particles whose current or personal best
+// parameters differ between p and q.
+func samePersonalBests(t *testing.T, p, q setpso.PsoInterface) {
+	for i := 0; i < p.Nparticles(); i++ {
+		if p.LocalBestTry(i).Parameter().Cmp(q.LocalBestTry(i).Parameter()) != 0 {
+			t.Errorf("particle %d personal best differs", i)
+		}
+		if p.CurrentTry(i).Parameter().Cmp(q.CurrentTry(i).Parameter()) != 0 {
+			t.Errorf("particle %d current parameter differs", i)
+		}
+	}
+}
+
+// checkpointPso is an SPSO that supports checkpoints.
+type checkpointPso interface {
+	setpso.PsoInterface
+	setpso.Checkpointer
+}
+
+func TestCheckpoint(t *testing.T) {
+	newFun := func() setpso.Fun { return subsetsum.New(100, 20, 3142) }
+	cases := []struct {
+		name string
+		new  func(sd int64) checkpointPso
+	}{
+		{"gpso", func(sd int64) checkpointPso {
+			return setpso.NewGPso(setpso.NewPso(20, newFun(), sd))
+		}},
+		{"clpso", func(sd int64) checkpointPso {
+			pso := setpso.NewCLPso(setpso.NewPso(20, newFun(), sd))
+			pso.TryGap = 5
+			return pso
+		}},
+		{"clpso1", func(sd int64) checkpointPso {
+			pso := setpso.NewCLPso1(setpso.NewPso(20, newFun(), sd), 3)
+			pso.TryGap = 5
+			return pso
+		}},
+		{"lpso", func(sd int64) checkpointPso {
+			return setpso.NewLPso(setpso.NewPso(20, newFun(), sd),
+				&setpso.RandomTopology{K: 3, Period: 7})
+		}},
+		{"fipso", func(sd int64) checkpointPso {
+			return setpso.NewFIPso(setpso.NewPso(20, newFun(), sd),
+				&setpso.VonNeumannTopology{}, setpso.RankWeights)
+		}},
+		{"bpso", func(sd int64) checkpointPso {
+			return setpso.NewBPso(setpso.NewPso(20, newFun(), sd))
+		}},
+		{"ga", func(sd int64) checkpointPso {
+			return setpso.NewGA(setpso.NewPso(20, newFun(), sd))
+		}},
+		{"sa", func(sd int64) checkpointPso {
+			return setpso.NewSA(setpso.NewPso(20, newFun(), sd))
+		}},
+		{"pbil", func(sd int64) checkpointPso {
+			return setpso.NewPBIL(setpso.NewPso(20, newFun(), sd), 2, 0.1)
+		}},
+		{"ccpso", func(sd int64) checkpointPso {
+			return newCCPso(newFun(), setpso.EqualRanges(100, 4), sd)
+		}},
+		{"apso", func(sd int64) checkpointPso {
+			return setpso.NewAPso(setpso.NewPso(20, newFun(), sd), 4, &setpso.OneFifthRule{
+				Ranges: []setpso.HeuristicRange{{Index: setpso.PhiHeuristic, Low: 0.5, High: 2.0}},
+				Factor: 1.2, Target: 0.2}, 7)
+		}},
+		{"apso-bandit", func(sd int64) checkpointPso {
+			return setpso.NewAPso(setpso.NewPso(20, newFun(), sd), 4, &setpso.BanditRule{
+				Indexes: []int{setpso.OmegaHeuristic},
+				Arms:    [][]float64{{0.6}, {0.73}, {0.85}}, C: 1}, 7)
+		}},
+	}
+	for _, c := range cases {
+		p := c.new(578)
+		for i := 0; i < 100; i++ {
+			p.Update()
+		}
+		var buf bytes.Buffer
+		if err := p.Checkpoint(&buf); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		q := c.new(99)
+		if err := q.Restore(&buf); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		samePersonalBests(t, p, q)
+		for i := 0; i < 100; i++ {
+			p.Update()
+			q.Update()
+		}
+		samePersonalBests(t, p, q)
+		if a, ok := p.(*setpso.APso); ok {
+			if b := q.(*setpso.APso); !reflect.DeepEqual(a.AdaptLog(), b.AdaptLog()) {
+				t.Errorf("%s: restored adaption log differs", c.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package setpso
+
+import "fmt"
+
+/*
+Combiner is the operation used to combine velocity probabilities during an
+update. Combine(p,q) should return a probability in the range 0.0 to 1.0 when p
+and q are in this range and is used to add the contribution q to an existing
+probability p. By default Pso uses PseudoAdd.
+*/
+type Combiner interface {
+	Combine(p, q float64) float64
+	// About gives a description of the operation.
+	About() string
+}
+
+// PseudoAdd combines probabilities p and q by pseudo adding to give p+q-pq.
+type PseudoAdd struct{}
+
+// Combine returns p+q-pq.
+func (c PseudoAdd) Combine(p, q float64) float64 {
+	// written this way to match the rounding of earlier versions
+	return p*(1.0-q) + q
+}
+
+// About gives a description of pseudo adding.
+func (c PseudoAdd) About() string { return "pseudo add p+q-pq" }
+
+// MaxProb combines probabilities p and q by taking the larger of the two.
+type MaxProb struct{}
+
+// Combine returns max(p,q).
+func (c MaxProb) Combine(p, q float64) float64 {
+	if p > q {
+		return p
+	}
+	return q
+}
+
+// About gives a description of taking the maximum.
+func (c MaxProb) About() string { return "maximum max(p,q)" }
+
+// CappedSum combines probabilities p and q by adding them and capping the
+// result at 1.0.
+type CappedSum struct{}
+
+// Combine returns min(1,p+q).
+func (c CappedSum) Combine(p, q float64) float64 {
+	if s := p + q; s < 1.0 {
+		return s
+	}
+	return 1.0
+}
+
+// About gives a description of the capped sum.
+func (c CappedSum) About() string { return "capped sum min(1,p+q)" }
+
+/*
+NoisyOr combines probabilities p and q as a noisy or where the contribution q is
+inhibited with probability Inhibit to give p+sq-psq with s = 1-Inhibit. With
+Inhibit = 0 this is the same as PseudoAdd.
+*/
+type NoisyOr struct {
+	Inhibit float64
+}
+
+// Combine returns p+sq-psq with s = 1-Inhibit.
+func (c NoisyOr) Combine(p, q float64) float64 {
+	q *= 1.0 - c.Inhibit
+	return p*(1.0-q) + q
+}
+
+// About gives a description of the noisy or.
+func (c NoisyOr) About() string {
+	return fmt.Sprintf("noisy or with inhibiting probability %f", c.Inhibit)
+}
+
+// SetCombiner sets the default Combiner used by groups that have not been
+// given their own Combiner.
+func (pso *Pso) SetCombiner(c Combiner) { pso.comb = c }
+
+// SetGroupCombiner sets the Combiner used by the group g; when c is nil the
+// group uses the default Combiner of pso.
+func (pso *Pso) SetGroupCombiner(g *Group, c Combiner) { g.comb = c }
+
+// GroupCombiner returns the Combiner used by the group g.
+func (pso *Pso) GroupCombiner(g *Group) Combiner {
+	if g.comb != nil {
+		return g.comb
+	}
+	return pso.comb
+}
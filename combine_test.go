@@ -0,0 +1,39 @@
+package setpso_test
+
+import (
+	"testing"
+
+	"github.com/mathrgo/setpso"
+)
+
+func TestCombiner(t *testing.T) {
+	combiners := []setpso.Combiner{setpso.PseudoAdd{}, setpso.MaxProb{},
+		setpso.CappedSum{}, setpso.NoisyOr{Inhibit: 0.3}}
+	probs := []float64{0.0, 0.1, 0.5, 0.9, 1.0}
+	for _, c := range combiners {
+		for _, p := range probs {
+			for _, q := range probs {
+				r := c.Combine(p, q)
+				if r < 0.0 || r > 1.0 {
+					t.Errorf("%s: Combine(%f,%f) = %f out of range", c.About(), p, q, r)
+				}
+				if r < p-1e-15 {
+					t.Errorf("%s: Combine(%f,%f) = %f reduces p", c.About(), p, q, r)
+				}
+			}
+		}
+	}
+	if r := (setpso.PseudoAdd{}).Combine(0.5, 0.5); r != 0.75 {
+		t.Errorf("pseudo adding 0.5 to 0.5 gives %f", r)
+	}
+	pso := newPso(10)
+	g := pso.Gr("root")
+	pso.SetCombiner(setpso.MaxProb{})
+	if _, ok := pso.GroupCombiner(g).(setpso.MaxProb); !ok {
+		t.Errorf("group does not use the swarm combiner")
+	}
+	pso.SetGroupCombiner(g, setpso.CappedSum{})
+	if _, ok := pso.GroupCombiner(g).(setpso.CappedSum); !ok {
+		t.Errorf("group does not use its own combiner")
+	}
+}
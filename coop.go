@@ -0,0 +1,359 @@
+package setpso
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+// BitRange is the range of Parameter bits from Begin up to but not including
+// End.
+type BitRange struct {
+	Begin, End int
+}
+
+// SplitterRanges returns the bit ranges of the parts of sp, which leave out
+// its padding bits.
+func SplitterRanges(sp *futil.Splitter) []BitRange {
+	r := make([]BitRange, sp.Parts())
+	for i := range r {
+		r[i].Begin, r[i].End = sp.BitRange(i)
+	}
+	return r
+}
+
+// EqualRanges splits maxLen bits into k, at least 1, contiguous ranges whose
+// sizes differ by at most 1.
+func EqualRanges(maxLen, k int) []BitRange {
+	if k < 1 {
+		k = 1
+	}
+	if k > maxLen {
+		k = maxLen
+	}
+	r := make([]BitRange, k)
+	for i := range r {
+		r[i].Begin = i * maxLen / k
+		r[i].End = (i + 1) * maxLen / k
+	}
+	return r
+}
+
+/*
+partTry is a try of a PartFun. Its Parameters are the bits of its range and
+the rest of its methods come from the try of the whole Parameters it was costed
+as.
+*/
+type partTry struct {
+	Try
+	x *big.Int
+}
+
+// Parameter returns the bits of the range.
+func (t *partTry) Parameter() *big.Int { return t.x }
+
+/*
+PartFun is the cost-function seen by a sub-swarm of a CCPso, which searches one
+BitRange of the Parameters of the cost-function it wraps. A Parameter of the
+PartFun is costed by putting its bits into the range of a shared context,
+which holds the Parameters of the best try found by any sub-swarm, and costing
+the result. UpdateCost() re-costs a try in the current context so that the
+Personal-bests keep up with the other sub-swarms. Decode() and Cost() of its
+tries describe the whole Parameters.
+*/
+type PartFun struct {
+	f   Fun
+	r   BitRange
+	ctx *big.Int
+	// mask of the range bits shifted down to bit 0
+	mask *big.Int
+	// scratch pads for the whole Parameters and the range bits
+	hint, temp *big.Int
+}
+
+// newPartFun returns the PartFun for bits r of f using the context ctx.
+func newPartFun(f Fun, r BitRange, ctx *big.Int) *PartFun {
+	pf := &PartFun{f: f, r: r, ctx: ctx, mask: new(big.Int), hint: new(big.Int),
+		temp: new(big.Int)}
+	pf.mask.Lsh(big.NewInt(1), uint(r.End-r.Begin))
+	pf.mask.Sub(pf.mask, big.NewInt(1))
+	return pf
+}
+
+// Range returns the bits searched.
+func (pf *PartFun) Range() BitRange { return pf.r }
+
+// part sets x to the bits of the range of z shifted down to bit 0.
+func (pf *PartFun) part(x, z *big.Int) {
+	x.Rsh(z, uint(pf.r.Begin))
+	x.And(x, pf.mask)
+}
+
+// join returns the context with its range bits replaced by z.
+func (pf *PartFun) join(z *big.Int) *big.Int {
+	pf.hint.Lsh(pf.mask, uint(pf.r.Begin))
+	pf.hint.AndNot(pf.ctx, pf.hint)
+	pf.temp.And(z, pf.mask)
+	return pf.hint.Or(pf.hint, pf.temp.Lsh(pf.temp, uint(pf.r.Begin)))
+}
+
+// NewTry returns a try of the default Parameters of the wrapped cost-function.
+func (pf *PartFun) NewTry() Try {
+	t := &partTry{Try: pf.f.NewTry(), x: new(big.Int)}
+	pf.part(t.x, t.Try.Parameter())
+	return t
+}
+
+// SetTry costs z in the current context.
+func (pf *PartFun) SetTry(t Try, z *big.Int) {
+	pt := t.(*partTry)
+	pf.f.SetTry(pt.Try, pf.join(z))
+	pf.part(pt.x, pt.Try.Parameter())
+}
+
+// Copy copies src to dest.
+func (pf *PartFun) Copy(dest, src Try) {
+	d := dest.(*partTry)
+	s := src.(*partTry)
+	pf.f.Copy(d.Try, s.Try)
+	d.x.Set(s.x)
+}
+
+// UpdateCost re-costs x in the current context if that satisfies the
+// constraints and otherwise as it is.
+func (pf *PartFun) UpdateCost(x Try) {
+	pt := x.(*partTry)
+	if z := pf.join(pt.x); z.Cmp(pt.Try.Parameter()) != 0 && pf.f.ToConstraint(pt.Try, z) {
+		pf.part(pt.x, pt.Try.Parameter())
+		return
+	}
+	pf.f.UpdateCost(pt.Try)
+}
+
+// Cmp compares the tries of the whole Parameters.
+func (pf *PartFun) Cmp(x, y Try, mode futil.CmpMode) float64 {
+	return pf.f.Cmp(x.(*partTry).Try, y.(*partTry).Try, mode)
+}
+
+// MaxLen returns the number of bits searched.
+func (pf *PartFun) MaxLen() int { return pf.r.End - pf.r.Begin }
+
+// About gives a description of the cost-function.
+func (pf *PartFun) About() string {
+	return fmt.Sprintf("bits %d to %d of:\n%s", pf.r.Begin, pf.r.End-1, pf.f.About())
+}
+
+// ToConstraint makes hint, put into the current context, satisfy the
+// constraints of the wrapped cost-function.
+func (pf *PartFun) ToConstraint(pre Try, hint *big.Int) bool {
+	pt := pre.(*partTry)
+	if !pf.f.ToConstraint(pt.Try, pf.join(hint)) {
+		return false
+	}
+	pf.part(pt.x, pt.Try.Parameter())
+	return true
+}
+
+// Delete deletes the ith item of the range.
+func (pf *PartFun) Delete(i int) bool { return pf.f.Delete(pf.r.Begin + i) }
+
+/*
+CCPso is a cooperative coevolution SPSO that splits the Parameters into
+BitRanges, each searched by its own sub-swarm, which can be any SPSO built on
+a Pso using the PartFun of its range. The sub-swarms share a context holding
+the Parameters of the best try found so far; each costs its tries by putting
+their bits into its range of the context. The sub-swarms are updated in turn
+and the context moves to the global best of a sub-swarm as soon as it is
+better. This suits structured Parameters such as those divided by a
+futil.Splitter, which SplitterRanges() turns into BitRanges.
+
+CCPso satisfies PsoInterface by numbering the particles of the sub-swarms one
+after the other; the tries of the particles describe the whole Parameters they
+were costed as.
+*/
+type CCPso struct {
+	f      Fun
+	swarms []Island
+	funs   []*PartFun
+	// best try of the whole Parameters, whose Parameters are the context
+	best Try
+	ctx  *big.Int
+	// particle number offset of each sub-swarm
+	offset []int
+}
+
+/*
+NewCCPso creates a CCPso for the cost-function f with a sub-swarm for each of
+ranges created by newSwarm from the PartFun of its range. The ranges should
+not overlap. The context starts at the default Parameters of f and then moves
+to the best global best of the sub-swarms.
+*/
+func NewCCPso(f Fun, ranges []BitRange, newSwarm func(k int, pf *PartFun) (Island, error)) (*CCPso, error) {
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("no bit ranges to search")
+	}
+	cc := &CCPso{f: f, best: f.NewTry()}
+	cc.ctx = new(big.Int).Set(cc.best.Parameter())
+	cc.offset = make([]int, len(ranges)+1)
+	for k, r := range ranges {
+		if r.Begin < 0 || r.End > f.MaxLen() || r.Begin >= r.End {
+			return nil, fmt.Errorf("bit range %d to %d is not within %d bits", r.Begin, r.End, f.MaxLen())
+		}
+		pf := newPartFun(f, r, cc.ctx)
+		p, err := newSwarm(k, pf)
+		if err != nil {
+			return nil, err
+		}
+		cc.funs = append(cc.funs, pf)
+		cc.swarms = append(cc.swarms, p)
+		cc.offset[k+1] = cc.offset[k] + p.Nparticles()
+	}
+	for _, p := range cc.swarms {
+		cc.offer(p)
+	}
+	return cc, nil
+}
+
+// offer moves the context to the global best of p if it is better.
+func (cc *CCPso) offer(p Island) {
+	t := p.LocalBestTry(p.BestParticle()).(*partTry).Try
+	if cc.f.Cmp(cc.best, t, futil.CostMode) > 0.0 {
+		cc.f.Copy(cc.best, t)
+		cc.ctx.Set(t.Parameter())
+	}
+}
+
+// Swarm returns the kth sub-swarm.
+func (cc *CCPso) Swarm(k int) Island { return cc.swarms[k] }
+
+// Nswarms returns the number of sub-swarms.
+func (cc *CCPso) Nswarms() int { return len(cc.swarms) }
+
+// Range returns the bits searched by the kth sub-swarm.
+func (cc *CCPso) Range(k int) BitRange { return cc.funs[k].r }
+
+// Best returns the best try of the whole Parameters found so far, whose
+// Parameters are the context.
+func (cc *CCPso) Best() Try { return cc.best }
+
+// locate returns the sub-swarm and its particle number for the ith particle.
+func (cc *CCPso) locate(i int) (k, j int) {
+	k = sort.SearchInts(cc.offset, i+1) - 1
+	return k, i - cc.offset[k]
+}
+
+// Update updates each sub-swarm in turn, moving the context to its global best
+// if it is better.
+func (cc *CCPso) Update() {
+	for _, p := range cc.swarms {
+		p.Update()
+		cc.offer(p)
+	}
+}
+
+// BestParticle returns the best particle over all sub-swarms.
+func (cc *CCPso) BestParticle() int {
+	best := 0
+	for k, p := range cc.swarms {
+		i := p.BestParticle()
+		if k == 0 || cc.f.Cmp(cc.LocalBestTry(best).(*partTry).Try,
+			p.LocalBestTry(i).(*partTry).Try, futil.CostMode) > 0.0 {
+			best = cc.offset[k] + i
+		}
+	}
+	return best
+}
+
+// Nparticles returns the total number of particles over all sub-swarms.
+func (cc *CCPso) Nparticles() int { return cc.offset[len(cc.swarms)] }
+
+// Part returns the ith particle.
+func (cc *CCPso) Part(i int) *Particle {
+	k, j := cc.locate(i)
+	return cc.swarms[k].Part(j)
+}
+
+// CurrentTry returns the current try of the ith particle.
+func (cc *CCPso) CurrentTry(i int) Try {
+	k, j := cc.locate(i)
+	return cc.swarms[k].CurrentTry(j)
+}
+
+// LocalBestTry returns the Personal-best try of the ith particle.
+func (cc *CCPso) LocalBestTry(i int) Try {
+	k, j := cc.locate(i)
+	return cc.swarms[k].LocalBestTry(j)
+}
+
+// PrintDebug outputs the debugging diagnostics of each sub-swarm in turn.
+func (cc *CCPso) PrintDebug(w io.Writer, cmd string) {
+	for k, p := range cc.swarms {
+		r := cc.funs[k].r
+		fmt.Fprintf(w, "sub-swarm %d bits %d to %d:\n", k, r.Begin, r.End-1)
+		p.PrintDebug(w, cmd)
+	}
+}
+
+// Heuristics returns the master heuristics of the first sub-swarm.
+func (cc *CCPso) Heuristics() *PsoHeuristics { return cc.swarms[0].Heuristics() }
+
+// SetHeuristics sets the heuristics of all the sub-swarms.
+func (cc *CCPso) SetHeuristics(hu *PsoHeuristics) {
+	for _, p := range cc.swarms {
+		p.SetHeuristics(hu)
+	}
+}
+
+// ccState is the stored form of a CCPso.
+type ccState struct {
+	Context *big.Int
+	Swarms  [][]byte
+}
+
+// Checkpoint writes the state of the CCPso to w. Each sub-swarm must be a
+// Checkpointer.
+func (cc *CCPso) Checkpoint(w io.Writer) error {
+	st := ccState{Context: cc.ctx}
+	for k, p := range cc.swarms {
+		c, ok := p.(Checkpointer)
+		if !ok {
+			return fmt.Errorf("sub-swarm %d does not support checkpoints", k)
+		}
+		var buf bytes.Buffer
+		if err := c.Checkpoint(&buf); err != nil {
+			return err
+		}
+		st.Swarms = append(st.Swarms, buf.Bytes())
+	}
+	return gob.NewEncoder(w).Encode(&st)
+}
+
+// Restore reads a state written by Checkpoint() from r and replaces the state
+// of the CCPso by it. The sub-swarm tries are re-costed in the restored
+// context.
+func (cc *CCPso) Restore(r io.Reader) error {
+	var st ccState
+	if err := gob.NewDecoder(r).Decode(&st); err != nil {
+		return err
+	}
+	if len(st.Swarms) != len(cc.swarms) || st.Context == nil {
+		return fmt.Errorf("checkpoint was not made by %d sub-swarms", len(cc.swarms))
+	}
+	cc.f.SetTry(cc.best, st.Context)
+	cc.ctx.Set(st.Context)
+	for k, p := range cc.swarms {
+		c, ok := p.(Checkpointer)
+		if !ok {
+			return fmt.Errorf("sub-swarm %d does not support checkpoints", k)
+		}
+		if err := c.Restore(bytes.NewReader(st.Swarms[k])); err != nil {
+			return fmt.Errorf("sub-swarm %d: %v", k, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package setpso_test
+
+import (
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/futil"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+// newCCPso returns a CCPso searching ranges of f with a GPso of 10 particles
+// for each range.
+func newCCPso(f setpso.Fun, ranges []setpso.BitRange, sd int64) *setpso.CCPso {
+	cc, err := setpso.NewCCPso(f, ranges, func(k int, pf *setpso.PartFun) (setpso.Island, error) {
+		return setpso.NewGPso(setpso.NewPso(10, pf, sd+int64(k))), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	return cc
+}
+
+func TestCCPso(t *testing.T) {
+	sp := futil.NewSplitter(4, 70, 8)
+	want := []setpso.BitRange{{0, 4}, {64, 134}, {192, 200}}
+	for i, r := range setpso.SplitterRanges(sp) {
+		if r != want[i] {
+			t.Errorf("range %d is %v, want %v", i, r, want[i])
+		}
+	}
+	for i, r := range setpso.EqualRanges(10, 3) {
+		if n := r.End - r.Begin; n < 3 || n > 4 {
+			t.Errorf("range %d has %d bits", i, n)
+		}
+	}
+	f := subsetsum.New(100, 20, 3142)
+	cc := newCCPso(subsetsum.New(100, 20, 3142), setpso.EqualRanges(100, 4), 578)
+	if cc.Nparticles() != 40 {
+		t.Fatalf("%d particles, want 40", cc.Nparticles())
+	}
+	start := f.NewTry()
+	f.SetTry(start, cc.Best().Parameter())
+	for i := 0; i < 100; i++ {
+		cc.Update()
+	}
+	best := f.NewTry()
+	f.SetTry(best, cc.Best().Parameter())
+	if f.Cmp(best, start, futil.CostMode) >= 0.0 {
+		t.Errorf("no improvement from cost %s", start.Cost())
+	}
+	if best.Cost() != cc.LocalBestTry(cc.BestParticle()).Cost() {
+		t.Errorf("best particle cost %s differs from best cost %s",
+			cc.LocalBestTry(cc.BestParticle()).Cost(), best.Cost())
+	}
+	// the particles only search their own range
+	for k := 0; k < cc.Nswarms(); k++ {
+		r := cc.Range(k)
+		p := cc.Swarm(k)
+		for i := 0; i < p.Nparticles(); i++ {
+			if p.CurrentTry(i).Parameter().BitLen() > r.End-r.Begin {
+				t.Errorf("sub-swarm %d particle %d is outside its range", k, i)
+			}
+		}
+	}
+}
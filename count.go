@@ -0,0 +1,87 @@
+package setpso
+
+import (
+	"math/big"
+	"sync/atomic"
+)
+
+/*
+EvalCounter counts the cost-function calls made through CountingFun. It is
+safe for concurrent use so it can be shared by the cost-function instances of
+the workers set up with SetWorkers().
+*/
+type EvalCounter struct {
+	setTry       int64
+	updateCost   int64
+	toConstraint int64
+}
+
+// Evaluations returns the total number of counted calls.
+func (c *EvalCounter) Evaluations() int64 {
+	setTry, updateCost, toConstraint := c.Counts()
+	return setTry + updateCost + toConstraint
+}
+
+// Counts returns the number of calls of SetTry(), UpdateCost() and
+// ToConstraint().
+func (c *EvalCounter) Counts() (setTry, updateCost, toConstraint int64) {
+	return atomic.LoadInt64(&c.setTry), atomic.LoadInt64(&c.updateCost),
+		atomic.LoadInt64(&c.toConstraint)
+}
+
+// Set sets the counts, which is used to carry on counting after a restore.
+func (c *EvalCounter) Set(setTry, updateCost, toConstraint int64) {
+	atomic.StoreInt64(&c.setTry, setTry)
+	atomic.StoreInt64(&c.updateCost, updateCost)
+	atomic.StoreInt64(&c.toConstraint, toConstraint)
+}
+
+// Reset sets the counts to zero.
+func (c *EvalCounter) Reset() { c.Set(0, 0, 0) }
+
+/*
+CountingFun wraps a cost-function so that every SetTry(), UpdateCost() and
+ToConstraint() call made through it is counted by its EvalCounter. This gives
+the number of cost-function evaluations used by an SPSO, which is the fair
+measure when comparing it with other algorithms. Calls made inside the wrapped
+cost-function, such as by NewTry(), are not counted.
+*/
+type CountingFun struct {
+	Fun
+	*EvalCounter
+}
+
+// NewCountingFun returns f wrapped to count calls using c, which is created
+// when c is nil.
+func NewCountingFun(f Fun, c *EvalCounter) *CountingFun {
+	if c == nil {
+		c = new(EvalCounter)
+	}
+	return &CountingFun{f, c}
+}
+
+// SetTry counts the call and sets t from z.
+func (f *CountingFun) SetTry(t Try, z *big.Int) {
+	atomic.AddInt64(&f.setTry, 1)
+	f.Fun.SetTry(t, z)
+}
+
+// UpdateCost counts the call and updates the cost of x.
+func (f *CountingFun) UpdateCost(x Try) {
+	atomic.AddInt64(&f.updateCost, 1)
+	f.Fun.UpdateCost(x)
+}
+
+// Sample counts the call as an UpdateCost() call and samples the cost of t
+// using the wrapped cost-function, which must be a Sampler.
+func (f *CountingFun) Sample(t Try, restart bool) float64 {
+	atomic.AddInt64(&f.updateCost, 1)
+	return f.Fun.(Sampler).Sample(t, restart)
+}
+
+// ToConstraint counts the call and makes pre satisfy the constraints from
+// hint.
+func (f *CountingFun) ToConstraint(pre Try, hint *big.Int) bool {
+	atomic.AddInt64(&f.toConstraint, 1)
+	return f.Fun.ToConstraint(pre, hint)
+}
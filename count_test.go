@@ -0,0 +1,31 @@
+package setpso_test
+
+import (
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+func TestCountingFun(t *testing.T) {
+	count := func(nw int) int64 {
+		c := new(setpso.EvalCounter)
+		p0 := setpso.NewPso(20, setpso.NewCountingFun(subsetsum.New(100, 20, 3142), c), 578)
+		p0.SetWorkers(nw, func() setpso.Fun {
+			return setpso.NewCountingFun(subsetsum.New(100, 20, 3142), c)
+		})
+		pso := setpso.NewGPso(p0)
+		for i := 0; i < 50; i++ {
+			pso.Update()
+		}
+		return c.Evaluations()
+	}
+	serial := count(1)
+	// each particle makes at least one evaluation an update
+	if serial < 50*20 {
+		t.Errorf("counted %d evaluations for 50 updates of 20 particles", serial)
+	}
+	if n := count(4); n != serial {
+		t.Errorf("workers counted %d evaluations but serial counted %d", n, serial)
+	}
+}
@@ -13,8 +13,8 @@ Relation to Other Sub Packages
 Package setpso lives in a directory that is at the top of a a hierarchy of
 packages.
 
-Package setpso contains two working SPSOs: GPso and CLPso that depend on Pso
-for all interfaces except Update() needed in package psokit.
+Package setpso contains working SPSOs such as GPso, CLPso, LPso, FIPso, APso,
+NPso and MOPso that depend on Pso for all interfaces except Update() needed in package psokit.
 
 Packages in setpso/fun is where cost-functions that interface with Pso are
 usually placed and includes any helper packages for such cost-functions.
@@ -43,8 +43,10 @@ to a desired one that may improve performance.
 
 during the calculation of the velocity of a particle probabilities are combined
 using  an operation called pseudo adding where by default probabilities p,q  are
-pseudo added to give p+q-pq. Alternatives may be considered in the future such
-as max(p,q) if only to show which is best.
+pseudo added to give p+q-pq. Alternatives can be chosen for the swarm or for
+each group through the Combiner interface to show which is best; those provided
+are PseudoAdd, MaxProb giving max(p,q), CappedSum giving min(1,p+q) and NoisyOr
+where the added probability is randomly inhibited.
 
 Grouping of particles
 
@@ -57,7 +59,11 @@ no targets, but forms most common interfaces and has a function PUpdate() that
 does the common velocity update. To create a functioning SPSO extra code is
 added before PUpdate() to choose Targets and Heuristics which are added by the
 derived working SPSOs to generate the total update iteration function, Update().
-GPso and CLPso are examples of such derived working SPSOs.
+GPso, CLPso, LPso, FIPso, APso, NPso and MOPso are examples of such derived
+working SPSOs; APso also adapts the heuristics of its groups from how often
+their particles improve, NPso regroups its particles into species by Hamming
+distance to find several optima at once and MOPso leads its groups from a
+Pareto archive to find the trade offs of a multi-objective cost-function.
 
 It is important to note that the collection of groups is stored as mapping from
 strings  to pointers to groups so groups can be accessed by name  if necessary
@@ -67,6 +73,111 @@ up there is only one group called "root" which contains all the particles.
 Additional groups  can be formed during initialisation or even during iteration
 and particles moved  between groups as and when required.
 
+Islands
+
+Islands runs several SPSOs, which can be of different variants and heuristics,
+as islands that swap their best Personal-bests along a neighbourhood graph every
+few updates. The islands can be updated on separate goroutines and the whole
+satisfies PsoInterface so it can be used by psokit like any other SPSO.
+
+Elite archive
+
+SetEliteArchive() keeps the best Personal-bests found that differ from each
+other in a minimum number of bits so that several distinct good solutions are
+available at the end of a run through the Archiver interface.
+
+Restarts
+
+SetRestart() lets a swarm that has collapsed onto one Personal-best escape by
+re-randomizing part of it once the global best stops improving, keeping the
+best particles and an archive of the best tries found.
+
+Cooperative coevolution
+
+CCPso splits the Parameters into BitRanges, such as the parts of a
+futil.Splitter, each searched by its own sub-swarm through a PartFun that costs
+the range bits in a context made from the best Parameters found so far.
+
+Multi-objective optimization
+
+Cost-functions with several costs to trade off can use futil.VecFunStub, whose
+tries hold a vector of costs and compare by Pareto dominance. MOPso keeps the
+non-dominated tries in a bounded ParetoArchive and a LeaderSelector, such as
+CrowdingLeaders, chooses a leader from it for each group of particles.
+
+Local search
+
+SetLocalSearch() makes a swarm memetic by running a LocalSearcher, such as
+BitFlipSearch, TwoFlipSearch or VNSearch, on its best Personal-bests every few
+updates to find the last few bit flips. The evaluations it makes are counted
+separately by LocalSearchEvaluations().
+
+Noisy costs
+
+Costs with noise are kept as statistics of their samples. futil.SFloatFunStub
+keeps a forgetting mean, whereas futil.VFloatFunStub also keeps the variance so
+that comparisons give the confidence of a Welch test of the means, which is
+what ThresholdHeuristic is compared with, and costs are reported with
+confidence intervals. The multimode and dag cost-functions can use either.
+
+Racing of noisy tries
+
+With a noisy cost, such as one built on futil.SFloatFunStub, whether a try is
+better than a Personal-best is uncertain. SetRacing() replaces the list of tries
+kept for this by a race each update between every particle's new try and its
+Personal-best, in which a Racer, such as OCBA, HoeffdingRace or TTestRace,
+shares out a budget of extra cost samples to the least certain comparisons and
+then decides them. The samples it draws are counted by RaceEvaluations().
+Racing is not the default, so without SetRacing() a noisy cost keeps using the
+list of tries, which draws no extra samples.
+
+Baselines
+
+BPso, the classic sigmoid binary PSO of Kennedy and Eberhart, GA, a steady
+state genetic algorithm with uniform crossover and bit mutation, and SA,
+simulated annealing with a chain for each particle, are built on Pso so that
+they can be run through psokit head to head with the SPSOs.
+
+Estimation of distribution
+
+PBIL learns the probability of each bit being 1 from the best of the tries it
+samples, much as the SPSO velocities hold probabilities of flipping bits. The
+entropy of its model, given through the Entropier interface, shows how far it
+has converged.
+
+Heuristic schedules
+
+Any float or int heuristic of the master heuristics can be made to follow a
+Schedule, which is a function of the number of updates done, using
+SetFloatSchedule() or SetIntSchedule(). Linear, exponential, cosine with
+restarts and piecewise table schedules are provided. The schedules are
+evaluated at the start of each Update() and psokit can set them by name.
+
+Checkpoints
+
+Long runs can be protected against crashes by saving the state of the swarm
+using Checkpoint() and rebuilding it later using Restore() on a swarm created
+with the same number of particles and cost-function. Pso, GPso, CLPso, LPso,
+FIPso, NPso, APso and MOPso support this through the Checkpointer interface, as do
+the baselines BPso, GA, SA and PBIL, while Islands and CCPso support it when
+their swarms do. psokit provides the checkpoint Action to do this during runs.
+
+Cost-function wrappers
+
+CountingFun counts the cost-function evaluations made by an SPSO so it can be
+compared with other algorithms on an evaluation budget. CachingFun keeps the
+costs of recently used parameters of a deterministic cost-function in a least
+recently used cache so that repeated costing, such as of the Personal-bests
+each update, is skipped.
+
+Sparse velocities
+
+For parameter spaces of 10^5 bits or more SetSparseVelocity() stores only the
+velocity components that stand out from a uniform background so that the
+memory and time of an update grow with the number of active bits rather than
+MaxLen(). The flipping probabilities are the same as in the dense form apart
+from a small cut off.
+
 setpso can be used in low level coding and the higher level run management is provided
 by the psokit toolkit package in
     import "github.com/mathrgo/setpso/psokit"
@@ -0,0 +1,154 @@
+package setpso
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+/*
+Entropier is the interface for optimizers that learn a probability model of
+good Parameters. The entropy of the model falls as the model converges.
+*/
+type Entropier interface {
+	// Entropy returns the entropy of the model in bits.
+	Entropy() float64
+}
+
+/*
+PBIL is population based incremental learning, an estimation of distribution
+algorithm that learns the probability of each bit of the Parameters being 1.
+Each update every particle samples a hint from the model, which is made to
+satisfy the constraints by ToConstraint() as in PUpdate(), and the current tries
+are ranked using Fun.Cmp(). The probability of each bit is then moved toward
+its mean over the best Mu current tries by
+
+	p = (1-LR)*p + LR*mean
+
+and kept within Margin of 0 and 1 so that no bit is fixed. A particle whose
+hint could not be made to satisfy the constraints keeps its last current try.
+The SPSO velocities are not used; the model starts with every probability 0.5.
+*/
+type PBIL struct {
+	*Pso
+	Mu         int
+	LR, Margin float64
+	// probability of each bit being 1
+	prob []float64
+	// scratch pads for sampling, ranking and counting bits
+	words []big.Word
+	rank  []int
+	count []float64
+}
+
+// NewPBIL creates a PBIL that learns from the best mu current tries, at least
+// 1, with learning rate lr and Margin = 1/MaxLen().
+func NewPBIL(p *Pso, mu int, lr float64) *PBIL {
+	if mu < 1 {
+		mu = 1
+	}
+	if mu > p.Nparticles() {
+		mu = p.Nparticles()
+	}
+	pso := &PBIL{Pso: p, Mu: mu, LR: lr, Margin: 1 / float64(p.maxLen)}
+	pso.prob = make([]float64, p.maxLen)
+	for j := range pso.prob {
+		pso.prob[j] = 0.5
+	}
+	pso.words = make([]big.Word, len(p.flip))
+	pso.rank = make([]int, p.Nparticles())
+	pso.count = make([]float64, p.maxLen)
+	return pso
+}
+
+//SetHeuristics sets the heuristics for the particle swarm.
+func (p *PBIL) SetHeuristics(hu *PsoHeuristics) { p.hu = hu }
+
+// Model returns a copy of the probability of each bit being 1.
+func (p *PBIL) Model() []float64 { return append([]float64(nil), p.prob...) }
+
+// Entropy returns the sum over the bits of the binary entropy of their
+// probabilities in bits, which is MaxLen() at the start.
+func (p *PBIL) Entropy() float64 {
+	h := 0.0
+	for _, q := range p.prob {
+		if q > 0 && q < 1 {
+			h -= q*math.Log2(q) + (1-q)*math.Log2(1-q)
+		}
+	}
+	return h
+}
+
+// Update samples a try for each particle from the model and then moves the
+// model toward the best of them.
+func (p *PBIL) Update() {
+	p.ApplySchedules()
+	for k := range p.Pt {
+		for i := range p.words {
+			p.words[i] = 0
+		}
+		for j, q := range p.prob {
+			if p.rnd.Float64() < q {
+				setWordBit(p.words, j)
+			}
+		}
+		p.Pt[k].hint.Set(p.flipMask.SetBits(p.words))
+	}
+	p.setAllParams()
+	p.learn()
+	p.endUpdate()
+}
+
+// learn moves the model toward the best Mu current tries.
+func (p *PBIL) learn() {
+	for i := range p.rank {
+		p.rank[i] = i
+	}
+	sort.SliceStable(p.rank, func(a, b int) bool {
+		return p.fun.Cmp(p.Pt[p.rank[b]].current,
+			p.Pt[p.rank[a]].current, futil.CostMode) > 0.0
+	})
+	for j := range p.count {
+		p.count[j] = 0
+	}
+	for _, i := range p.rank[:p.Mu] {
+		ForEachBit(p.Pt[i].current.Parameter(), func(j int) {
+			if j < len(p.count) {
+				p.count[j]++
+			}
+		})
+	}
+	for j := range p.prob {
+		q := (1-p.LR)*p.prob[j] + p.LR*p.count[j]/float64(p.Mu)
+		p.prob[j] = math.Min(math.Max(q, p.Margin), 1-p.Margin)
+	}
+}
+
+// Checkpoint writes the state of the PBIL including its model to w.
+func (p *PBIL) Checkpoint(w io.Writer) error {
+	st := p.state()
+	st.Model = p.Model()
+	return gob.NewEncoder(w).Encode(st)
+}
+
+// Restore reads a state written by Checkpoint() from r and replaces the state
+// of the PBIL by it.
+func (p *PBIL) Restore(r io.Reader) error {
+	var st psoState
+	if err := gob.NewDecoder(r).Decode(&st); err != nil {
+		return err
+	}
+	if len(st.Model) != len(p.prob) {
+		return fmt.Errorf("checkpoint was not made by a PBIL with %d bits", len(p.prob))
+	}
+	if err := p.setState(&st); err != nil {
+		return err
+	}
+	copy(p.prob, st.Model)
+	return nil
+}
@@ -0,0 +1,34 @@
+package setpso_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/futil"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+func TestPBIL(t *testing.T) {
+	f := subsetsum.New(100, 20, 3142)
+	pso := setpso.NewPBIL(newPso(10), 2, 0.1)
+	if h := pso.Entropy(); math.Abs(h-100) > 1e-9 {
+		t.Errorf("initial entropy is %f, want 100", h)
+	}
+	start := f.NewTry()
+	f.Copy(start, pso.LocalBestTry(pso.BestParticle()))
+	for i := 0; i < 300; i++ {
+		pso.Update()
+	}
+	if gb := pso.LocalBestTry(pso.BestParticle()); f.Cmp(gb, start, futil.CostMode) >= 0.0 {
+		t.Errorf("no improvement from cost %s", start.Cost())
+	}
+	if h := pso.Entropy(); h >= 50 {
+		t.Errorf("entropy %f has not fallen", h)
+	}
+	for j, q := range pso.Model() {
+		if q < pso.Margin || q > 1-pso.Margin {
+			t.Errorf("probability %f of bit %d is outside the margin", q, j)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package setpso
+
+import (
+	"math/big"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+/*
+Archiver is the interface for SPSOs that keep an archive of distinct good
+tries found during a run. Pso and the SPSOs built on it support this once
+SetEliteArchive() has been called.
+*/
+type Archiver interface {
+	// Elite returns the archived tries in order of increasing cost.
+	Elite() []Try
+}
+
+/*
+eliteArchive keeps up to size of the best tries offered to it in order of
+increasing cost where the Parameters of any two differ in at least minDist
+bits.
+*/
+type eliteArchive struct {
+	size    int
+	minDist int
+	tries   []Try
+	// scratch pad for Hamming distances
+	diff *big.Int
+}
+
+func newEliteArchive(size, minDist int) *eliteArchive {
+	if minDist < 1 {
+		// entries always differ
+		minDist = 1
+	}
+	return &eliteArchive{size: size, minDist: minDist, diff: new(big.Int)}
+}
+
+/*
+add offers a copy of t to the archive. If an entry within minDist of t is as
+good as t then t is not added, otherwise t replaces all the entries within
+minDist of it. It returns true if t is added.
+*/
+func (a *eliteArchive) add(f Fun, t Try) bool {
+	// k is the position of t after the entries that are as good
+	k := 0
+	near := 0
+	for _, e := range a.tries {
+		better := f.Cmp(t, e, futil.CostMode) < 0.0
+		if a.near(e, t) {
+			if !better {
+				return false
+			}
+			near++
+		} else if !better {
+			k++
+		}
+	}
+	if near == 0 && k >= a.size {
+		return false
+	}
+	// drop the near entries keeping one to reuse
+	var c Try
+	kept := a.tries[:0]
+	for _, e := range a.tries {
+		if a.near(e, t) {
+			c = e
+			continue
+		}
+		kept = append(kept, e)
+	}
+	a.tries = kept
+	if c == nil {
+		if len(a.tries) >= a.size {
+			c = a.tries[len(a.tries)-1]
+			a.tries = a.tries[:len(a.tries)-1]
+		} else {
+			c = f.NewTry()
+		}
+	}
+	f.Copy(c, t)
+	a.tries = append(a.tries, nil)
+	copy(a.tries[k+1:], a.tries[k:])
+	a.tries[k] = c
+	return true
+}
+
+// near returns true if the Parameters of x and y differ in less than minDist
+// bits.
+func (a *eliteArchive) near(x, y Try) bool {
+	a.diff.Xor(x.Parameter(), y.Parameter())
+	return CardinalSize(a.diff) < a.minDist
+}
+
+// params returns copies of the Parameters of the entries.
+func (a *eliteArchive) params() []*big.Int {
+	x := make([]*big.Int, len(a.tries))
+	for j, e := range a.tries {
+		x[j] = new(big.Int).Set(e.Parameter())
+	}
+	return x
+}
+
+// setParams rebuilds the entries from the Parameters x, which are assumed to
+// be in order of increasing cost.
+func (a *eliteArchive) setParams(f Fun, x []*big.Int) {
+	a.tries = a.tries[:0]
+	for _, z := range x {
+		t := f.NewTry()
+		f.SetTry(t, z)
+		a.tries = append(a.tries, t)
+	}
+}
+
+/*
+SetEliteArchive turns on an archive of up to size of the best Personal-bests
+found during the run whose Parameters differ in at least minDist bits, so that
+good alternative solutions are kept after the particles have moved on. The
+Personal-bests are offered to the archive at the end of each PUpdate(). When
+an offered try is better than all the entries within minDist of it, it replaces
+them. size = 0 turns the archive off. The costs of archived tries are not
+re-evaluated.
+*/
+func (pso *Pso) SetEliteArchive(size, minDist int) {
+	if size <= 0 {
+		pso.elite = nil
+		return
+	}
+	pso.elite = newEliteArchive(size, minDist)
+	pso.offerElite()
+}
+
+// Elite returns the tries in the elite archive in order of increasing cost;
+// it is empty when the archive is off.
+func (pso *Pso) Elite() []Try {
+	if pso.elite == nil {
+		return nil
+	}
+	return append([]Try(nil), pso.elite.tries...)
+}
+
+// offerElite offers the Personal-bests to the elite archive.
+func (pso *Pso) offerElite() {
+	for i := range pso.Pt {
+		pso.elite.add(pso.fun, pso.Pt[i].bestTry)
+	}
+}
@@ -0,0 +1,38 @@
+package setpso_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/futil"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+func TestEliteArchive(t *testing.T) {
+	f := subsetsum.New(100, 20, 3142)
+	pso := setpso.NewGPso(setpso.NewPso(20, f, 578))
+	pso.SetEliteArchive(5, 3)
+	for i := 0; i < 300; i++ {
+		pso.Update()
+	}
+	var ar setpso.Archiver = pso
+	e := ar.Elite()
+	if len(e) != 5 {
+		t.Fatalf("archive has %d tries", len(e))
+	}
+	if f.Cmp(pso.LocalBestTry(pso.BestParticle()), e[0], futil.CostMode) < 0.0 {
+		t.Errorf("archive best %s is worse than the global best", e[0].Cost())
+	}
+	diff := new(big.Int)
+	for a := range e {
+		if a > 0 && f.Cmp(e[a], e[a-1], futil.CostMode) < 0.0 {
+			t.Errorf("archive is not in order of cost at %d", a)
+		}
+		for b := a + 1; b < len(e); b++ {
+			if d := setpso.CardinalSize(diff.Xor(e[a].Parameter(), e[b].Parameter())); d < 3 {
+				t.Errorf("archive entries %d and %d differ in %d bits", a, b, d)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package setpso
+
+import (
+	"sort"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+/*
+TargetWeighting sets weights[i] to the weight of the particle targets[i] where
+the targets are sorted with the best Personal-best first. The weights are
+used by FIPso.
+*/
+type TargetWeighting func(targets []int, weights []float64)
+
+// UniformWeights shares the weights equally so they add up to 1.0.
+func UniformWeights(targets []int, weights []float64) {
+	for i := range weights {
+		weights[i] = 1.0 / float64(len(weights))
+	}
+}
+
+/*
+RankWeights gives weights proportional to the rank of the target so that the
+best target of k targets has weight proportional to k and the worst has weight
+proportional to 1. The weights add up to 1.0.
+*/
+func RankWeights(targets []int, weights []float64) {
+	k := len(weights)
+	sum := float64(k*(k+1)) / 2
+	for i := range weights {
+		weights[i] = float64(k-i) / sum
+	}
+}
+
+/*
+FIPso is a fully-informed PSO where each particle targets all of its neighbours
+in a neighbourhood graph rather than just the best one. Each target's
+contribution to the velocity update is weighted by a TargetWeighting computed
+from the ranking of the neighbours' Personal-best costs using Fun.Cmp(). Since
+the weights add up to 1.0 the combined pull of the neighbours is similar to
+that of a single target in LPso, which it is built on.
+*/
+type FIPso struct {
+	*LPso
+	weighting TargetWeighting
+	// scratch pads for sorting targets and their weights
+	targets []int
+	weights []float64
+}
+
+// NewFIPso creates a FIPso using the neighbourhood graph top and target
+// weighting wt.
+func NewFIPso(p *Pso, top Topology, wt TargetWeighting) *FIPso {
+	return &FIPso{LPso: NewLPso(p, top), weighting: wt}
+}
+
+/*
+Update rewires the neighbourhood graph if the Topology asks for it and then
+sets the targets of each particle to all its neighbours sorted with the best
+Personal-best first together with their weights. After this it does the usual
+PUpdate().
+*/
+func (p *FIPso) Update() {
+	p.ApplySchedules()
+	if p.top.Rewire(p.iter) {
+		p.top.Wire(p.nb, p.rnd)
+	}
+	for i := range p.nb {
+		p.targets = append(p.targets[:0], p.nb[i]...)
+		sort.SliceStable(p.targets, func(a, b int) bool {
+			return p.fun.Cmp(p.Pt[p.targets[b]].bestTry,
+				p.Pt[p.targets[a]].bestTry, futil.CostMode) > 0.0
+		})
+		if cap(p.weights) < len(p.targets) {
+			p.weights = make([]float64, len(p.targets))
+		}
+		p.weights = p.weights[:len(p.targets)]
+		p.weighting(p.targets, p.weights)
+		p.SetGroupWeightedTargets(p.Group(i), p.targets, p.weights)
+	}
+	p.PUpdate()
+	p.iter++
+}
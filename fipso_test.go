@@ -0,0 +1,24 @@
+package setpso_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+)
+
+func TestRankWeights(t *testing.T) {
+	targets := []int{3, 1, 2}
+	weights := make([]float64, 3)
+	setpso.RankWeights(targets, weights)
+	sum := 0.0
+	for i := range weights {
+		sum += weights[i]
+		if i > 0 && weights[i] >= weights[i-1] {
+			t.Errorf("weight %d = %f is not less than the previous weight", i, weights[i])
+		}
+	}
+	if math.Abs(sum-1.0) > 1e-12 {
+		t.Errorf("weights add up to %f", sum)
+	}
+}
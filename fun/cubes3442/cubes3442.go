@@ -109,6 +109,13 @@ func New(bitLen int) *IntFunStub {
 	return futil.NewIntFunStub(&f)
 }
 
+/*Splitter returns the Splitter that divides the Parameters into the parts
+used for j0, j1, j2 and the flags, which a cooperative SPSO can search
+separately.*/
+func (f *Fun) Splitter() *futil.Splitter {
+	return f.sp
+}
+
 //CreateData creates a empty structure for decoded try
 func (f *Fun) CreateData() TryData {
 	t := new(FunTryData)
@@ -342,6 +342,30 @@ type FloatFunStub = futil.SFloatFunStub
 func NewFunFloat(nnode, nbitslookback int, opt OptFloat, sizeCostFactor float64,
 	sampler SamplerFloat, sampleSize int,
 	rnd *rand.Rand, Tc, sigmaThres float64) *FloatFunStub {
+	f := newFunFloat(nnode, nbitslookback, opt, sizeCostFactor, sampler, sampleSize, rnd, Tc, sigmaThres)
+	return futil.NewSFloatFunStub(f, Tc, sigmaThres)
+}
+
+//VFloatFunStub gives interface to setpso with variance-aware costs
+type VFloatFunStub = futil.VFloatFunStub
+
+/*
+NewFunFloatV returns a new DAG cost-function as NewFunFloat does but whose
+costs use futil.VFloatCostValue, which compares costs by a Welch test of their
+means; sigmaThres is then the margin in standard errors for a try on the tries
+list to be better.
+*/
+func NewFunFloatV(nnode, nbitslookback int, opt OptFloat, sizeCostFactor float64,
+	sampler SamplerFloat, sampleSize int,
+	rnd *rand.Rand, Tc, sigmaThres float64) *VFloatFunStub {
+	f := newFunFloat(nnode, nbitslookback, opt, sizeCostFactor, sampler, sampleSize, rnd, Tc, sigmaThres)
+	return futil.NewVFloatFunStub(f, Tc, sigmaThres)
+}
+
+// newFunFloat returns the *FunFloat used by NewFunFloat.
+func newFunFloat(nnode, nbitslookback int, opt OptFloat, sizeCostFactor float64,
+	sampler SamplerFloat, sampleSize int,
+	rnd *rand.Rand, Tc, sigmaThres float64) *FunFloat {
 	nvar := sampler.InputSize()
 	nout := sampler.OutputSize()
 	var f FunFloat
@@ -359,7 +383,7 @@ func NewFunFloat(nnode, nbitslookback int, opt OptFloat, sizeCostFactor float64,
 	f.Tc = Tc
 	f.sigmaThres = sigmaThres
 
-	return futil.NewSFloatFunStub(&f, Tc, sigmaThres)
+	return &f
 }
 
 // SetSizeCostFactor sets factor weight to include INode usage cost
@@ -383,9 +407,17 @@ using random samples.
 */
 func (f *FunFloat) Cost(data TryData) (cost float64) {
 	d := data.(*DTryData)
-	f.difCost = 0.0
 	sCost := float64(d.structureCost)
 	cost = sCost * f.sizeCostFactor
+	cost += f.mismatch(d)
+	return
+
+}
+
+// mismatch returns the mean over random samples of the sum of squares of the
+// output mismatches.
+func (f *FunFloat) mismatch(d *DTryData) float64 {
+	f.difCost = 0.0
 	nout := f.sampler.OutputSize()
 	for j := 0; j < f.sampleSize; j++ {
 		f.sampler.Sample(f.input, f.output, f.rnd)
@@ -423,9 +455,7 @@ func (f *FunFloat) Cost(data TryData) (cost float64) {
 	}
 	//fmt.Printf("difCost= %f\n",f.difCost)
 	f.difCost /= float64(f.sampleSize)
-	cost += f.difCost
-	return
-
+	return f.difCost
 }
 
 //DefaultParam gives a default that satisfies constraints
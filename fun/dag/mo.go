@@ -0,0 +1,54 @@
+package dag
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+/*
+FunFloatMO is a two objective version of FunFloat that keeps the output
+mismatch and the node usage cost apart instead of mixing them through
+sizeCostFactor, so that the trade off between them can be found as a Pareto
+front.
+*/
+type FunFloatMO struct {
+	*FunFloat
+}
+
+// VecFunStub gives interface to setpso
+type VecFunStub = futil.VecFunStub
+
+/*
+NewFunFloatMO returns a new two objective DAG cost-function ready to be used
+with a multi-objective SPSO. The arguments are as for NewFunFloat. The
+mismatch is estimated from sampleSize random samples each time a try is costed
+so a large sample size is needed for Pareto dominance to be reliable.
+*/
+func NewFunFloatMO(nnode, nbitslookback int, opt OptFloat,
+	sampler SamplerFloat, sampleSize int, rnd *rand.Rand) *VecFunStub {
+	f := newFunFloat(nnode, nbitslookback, opt, 0.0, sampler, sampleSize, rnd, 0.0, 0.0)
+	return futil.NewVecFunStub(&FunFloatMO{f})
+}
+
+// NObjectives returns the number of objectives, which is 2.
+func (f *FunFloatMO) NObjectives() int { return 2 }
+
+/*
+Cost puts the mean over random samples of the sum of squares of the output
+mismatches into cost[0] and the node usage cost into cost[1].
+*/
+func (f *FunFloatMO) Cost(data TryData, cost []float64) {
+	d := data.(*DTryData)
+	cost[0] = f.mismatch(d)
+	cost[1] = float64(d.structureCost)
+}
+
+// About string gives a description of the cost function
+func (f *FunFloatMO) About() string {
+	s := fmt.Sprintf("Two objective Dag using operation: %s\n", f.opt.About())
+	s += fmt.Sprintf("Sampler: %s\n ", f.sampler.About())
+	s += fmt.Sprintf("sample size %d", f.sampleSize)
+	return s
+}
@@ -99,6 +99,19 @@ func (sp *Splitter) MaxBits() int {
 	return sp.maxBits
 }
 
+// Parts returns the number of parts.
+func (sp *Splitter) Parts() int {
+	return len(sp.bits)
+}
+
+/*BitRange returns the bits of the joined big int used by part i, which run from
+begin up to but not including end. The padding bits that follow are not
+included.*/
+func (sp *Splitter) BitRange(i int) (begin, end int) {
+	begin = sp.offset[i] * W
+	return begin, begin + sp.bits[i]
+}
+
 /*Split takes a copy of the absolute value of x and splits it up into positive
 big int parts in place ensuring the parts are word aligned. While doing this it
 modifies the copy to match the splitting so that each part is a sub slice of
@@ -42,6 +42,18 @@ func ExampleSplitter_Join() {
 	 */
 }
 
+func ExampleSplitter_BitRange() {
+	sp := NewSplitter(4, 70, 8)
+	for i := 0; i < sp.Parts(); i++ {
+		begin, end := sp.BitRange(i)
+		fmt.Printf("part %d: bits %d to %d\n", i, begin, end-1)
+	}
+	// Output:
+	// part 0: bits 0 to 3
+	// part 1: bits 64 to 133
+	// part 2: bits 192 to 199
+}
+
 func ExampleNewSFloatCostValue() {
 	Tc := 100.0
 	c := NewSFloatCostValue(Tc)
@@ -51,3 +63,36 @@ func ExampleNewSFloatCostValue() {
 	/* Output:
 	 */
 }
+
+func ExampleVFloatCostValue_Cmp() {
+	a := NewVFloatCostValue(100)
+	b := NewVFloatCostValue(100)
+	a.Set(1.0)
+	b.Set(2.0)
+	// the variances are not known yet
+	fmt.Printf("%.3f\n", a.Cmp(b, CostMode))
+	for _, x := range []float64{1.1, 0.9, 1.0} {
+		a.Update(x)
+	}
+	for _, x := range []float64{2.2, 1.8, 2.0} {
+		b.Update(x)
+	}
+	// b's mean is greater so a is confidently better
+	fmt.Printf("%.3f %.3f\n", a.Cmp(b, CostMode), b.Cmp(a, CostMode))
+	lo, hi := a.Interval(0.95)
+	fmt.Printf("mean %.3f in [%.3f,%.3f]\n", a.Mean(), lo, hi)
+	// Output:
+	// 0.500
+	// 1.000 -1.000
+	// mean 1.000 in [0.870,1.130]
+}
+
+func ExampleDominates() {
+	fmt.Println(Dominates([]float64{1, 2}, []float64{1, 3}))
+	fmt.Println(Dominates([]float64{1, 2}, []float64{1, 2}))
+	fmt.Println(Dominates([]float64{0, 3}, []float64{1, 2}))
+	// Output:
+	// true
+	// false
+	// false
+}
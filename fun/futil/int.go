@@ -94,6 +94,9 @@ type IntFunStub struct {
 	tempCost *big.Int
 }
 
+//Fun retrieves the internal cost function
+func (f *IntFunStub) Fun() IntFun { return f.IntFun }
+
 //NewIntFunStub creates an instance of the IntFunStub ready for use as the interface setpso.Fun
 func NewIntFunStub(f IntFun) *IntFunStub {
 	stub := new(IntFunStub)
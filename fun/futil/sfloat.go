@@ -138,6 +138,19 @@ func (f *SFloatFunStub) UpdateCost(t Try) {
 	try.cost.Update(f.Cost(try.TryData))
 }
 
+//Sample recalculates the try cost as UpdateCost does and returns the new sample;
+//when restart is true the stats of the cost start afresh from the new sample
+func (f *SFloatFunStub) Sample(t Try, restart bool) float64 {
+	try := t.(*SFloatTry)
+	x := f.Cost(try.TryData)
+	if restart {
+		try.cost.Reset(x)
+	} else {
+		try.cost.Update(x)
+	}
+	return x
+}
+
 //Cmp compares the tries
 func (f *SFloatFunStub) Cmp(x, y Try, mode CmpMode) float64 {
 	s := x.(*SFloatTry)
@@ -224,6 +237,13 @@ func (c *SFloatCostValue) Set(x float64) {
 	c.updateSum = 1.0
 }
 
+//Reset starts the stats afresh from x, which unlike Set also restarts the effective sum of costs
+func (c *SFloatCostValue) Reset(x float64) {
+	c.mean = x
+	c.costSum = x
+	c.updateSum = 1.0
+}
+
 //Update adds the mean of x as raw data to calculate an updated stats of the cost value
 func (c *SFloatCostValue) Update(x float64) {
 	c.updateSum *= c.alpha
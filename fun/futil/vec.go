@@ -0,0 +1,187 @@
+package futil
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+/*
+VecTry is the data type used to store a try with a vector of floating point
+costs, one for each objective of a multi-objective cost function, where the
+costs are functions of the parameter x.
+*/
+type VecTry struct {
+	x *big.Int
+	TryData
+	cost []float64
+}
+
+// NewVecTry is a convenience function for generating a new vector costed try
+// with n objectives.
+func NewVecTry(z *big.Int, data TryData, n int) *VecTry {
+	t := new(VecTry)
+	t.x = new(big.Int)
+	t.x.Set(z)
+	t.TryData = data
+	t.cost = make([]float64, n)
+	return t
+}
+
+//Parameter reads the try value
+func (t *VecTry) Parameter() *big.Int {
+	return t.x
+}
+
+//Decode gives a human readable description of decoded try data
+func (t *VecTry) Decode() string {
+	return t.TryData.Decode()
+}
+
+// Cost returns a human readable cost description
+func (t *VecTry) Cost() string {
+	s := make([]string, len(t.cost))
+	for i, c := range t.cost {
+		s[i] = fmt.Sprintf("%f", c)
+	}
+	return " [" + strings.Join(s, " ") + "]"
+}
+
+// CostVector returns the stored costs, which should not be modified.
+func (t *VecTry) CostVector() []float64 {
+	return t.cost
+}
+
+/*
+Cmp compares the costs of t with s by Pareto dominance. It returns 1 if s
+dominates t, that is s has no cost greater than t and at least one cost less,
+and -1 otherwise, which includes equal costs.
+*/
+func (t *VecTry) Cmp(s *VecTry) float64 {
+	if Dominates(s.cost, t.cost) {
+		return 1
+	}
+	return -1
+}
+
+//Data returns the decoded data
+func (t *VecTry) Data() TryData {
+	return t.TryData
+}
+
+/*Fbits gives a floating point measure of number of bits in the sum of the
+costs as for FloatTry, which gives a single value for plotting that decreases
+when the try improves in Pareto dominance.
+*/
+func (t *VecTry) Fbits() float64 {
+	c := 0.0
+	for _, x := range t.cost {
+		c += x
+	}
+	if c > 0 {
+		return math.Log2(1.0 + c)
+	}
+	return -math.Log2(1 - c)
+}
+
+/*
+Dominates returns true if the cost vector x Pareto dominates y, that is no
+component of x is greater than that of y and at least one is less.
+*/
+func Dominates(x, y []float64) bool {
+	less := false
+	for i := range x {
+		if x[i] > y[i] {
+			return false
+		}
+		if x[i] < y[i] {
+			less = true
+		}
+	}
+	return less
+}
+
+// VecCoster is implemented by tries that have a vector of costs.
+type VecCoster interface {
+	// CostVector returns the costs, one for each objective.
+	CostVector() []float64
+}
+
+//VecFun is the interface for vector costed functions
+type VecFun interface {
+	Fun
+	// number of objectives
+	NObjectives() int
+	// calculates the costs of the try using the decoded data, putting them in cost
+	Cost(data TryData, cost []float64)
+}
+
+/*
+VecFunStub uses VecFun interface to create the setpso.Fun interface for a
+multi-objective cost function. Its Cmp() uses Pareto dominance so a try only
+counts as better than another when it dominates it.
+*/
+type VecFunStub struct {
+	VecFun
+}
+
+//Fun retrieves the internal cost function
+func (f *VecFunStub) Fun() VecFun { return f.VecFun }
+
+//NewVecFunStub creates an instance of the VecFunStub ready for use as the interface setpso.Fun
+func NewVecFunStub(f VecFun) *VecFunStub {
+	stub := new(VecFunStub)
+	stub.VecFun = f
+	return stub
+}
+
+//NewTry creates a try as a VecTry
+func (f *VecFunStub) NewTry() Try {
+	try := NewVecTry(f.DefaultParam(), f.CreateData(), f.NObjectives())
+	f.IDecode(try.TryData, try.Parameter())
+	f.Cost(try.TryData, try.cost)
+	return try
+}
+
+//SetTry sets try  to a new parameter z
+func (f *VecFunStub) SetTry(t Try, z *big.Int) {
+	try := t.(*VecTry)
+	try.x.Set(z)
+	f.IDecode(try.TryData, try.Parameter())
+	f.Cost(try.TryData, try.cost)
+}
+
+//Copy copies src to dest
+func (f *VecFunStub) Copy(dest, src Try) {
+	d := dest.(*VecTry)
+	s := src.(*VecTry)
+	d.x.Set(s.x)
+	f.CopyData(d.TryData, s.TryData)
+	copy(d.cost, s.cost)
+}
+
+//UpdateCost recalculates the try cost
+func (f *VecFunStub) UpdateCost(t Try) {
+	try := t.(*VecTry)
+	f.Cost(try.TryData, try.cost)
+}
+
+//Cmp compares the tries
+func (f *VecFunStub) Cmp(x, y Try, mode CmpMode) float64 {
+	s := x.(*VecTry)
+	t := y.(*VecTry)
+	return s.Cmp(t)
+}
+
+// ToConstraint uses the previous try pre and the updating hint parameter
+// to attempt to produce an update to pre which satisfies
+// solution constraints it returns valid = True if succeeds, otherwise pre remains un changed and returns false
+func (f *VecFunStub) ToConstraint(pre Try, hint *big.Int) bool {
+	p := pre.(*VecTry)
+	if f.Constraint(p.TryData, hint) {
+		f.SetTry(pre, hint)
+		return true
+	}
+	return false
+}
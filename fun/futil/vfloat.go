@@ -0,0 +1,403 @@
+package futil
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// VFloatTry is the data type used to store a noisy floating point costed try
+// whose cost keeps its variance.
+type VFloatTry struct {
+	x *big.Int
+	TryData
+	cost *VFloatCostValue
+}
+
+//Parameter reads the try value
+func (t *VFloatTry) Parameter() *big.Int {
+	return t.x
+}
+
+// NewVFloatTry is a convenience function for generating an
+// new floating point costed try. Tc is the cost update timeconstant in iterations
+func NewVFloatTry(z *big.Int, data TryData, Tc float64) *VFloatTry {
+	t := new(VFloatTry)
+	t.x = new(big.Int)
+	t.x.Set(z)
+	t.TryData = data
+	t.cost = NewVFloatCostValue(Tc)
+	return t
+}
+
+//Decode gives a human readable description of decoded try data
+func (t *VFloatTry) Decode() string {
+	return t.TryData.Decode()
+}
+
+// Cost returns a human readable cost description including the 95%
+// confidence interval of the mean
+func (t *VFloatTry) Cost() string {
+	return t.cost.String()
+}
+
+//SetCostValue is used to set the cost value.
+func (t *VFloatTry) SetCostValue(c float64) {
+	t.cost.Set(c)
+}
+
+//CostValue returns the stored cost value
+func (t *VFloatTry) CostValue() *VFloatCostValue {
+	return t.cost
+}
+
+//Cmp compares  the cost of t with s where t is of type *VFloatTry
+func (t *VFloatTry) Cmp(s *VFloatTry, mode CmpMode) float64 {
+	return t.cost.Cmp(s.cost, mode)
+}
+
+//Data returns the decoded data
+func (t *VFloatTry) Data() TryData {
+	return t.TryData
+}
+
+// fbits is the scaling of a cost used by Fbits().
+func fbits(cost float64) float64 {
+	if cost > 0 {
+		return math.Log2(1.0 + cost)
+	}
+	return -math.Log2(1 - cost)
+}
+
+/*Fbits gives a floating point measure of number of bits in the
+mean cost as SFloatTry does.
+*/
+func (t *VFloatTry) Fbits() float64 {
+	return t.cost.Fbits()
+}
+
+// VFloatFunStub uses SFloatFun interface to create the setpso.Fun interface
+// with tries that are costed using VFloatCostValue
+type VFloatFunStub struct {
+	SFloatFun
+	// initial time  constant of try cost updates
+	Tc float64
+	//margin in standard errors for a try on the tries list to be better
+	SigmaMargin float64
+}
+
+//Fun retrieves the internal cost function
+func (f *VFloatFunStub) Fun() SFloatFun { return f.SFloatFun }
+
+/*NewVFloatFunStub creates an instance of the VFloatFunStub ready for use as
+the interface setpso.Fun. Tc is the initial try cost update time constant and
+SigmaMargin is the number of standard errors by which a try on the tries list
+must be better than the Personal-best to replace it.
+*/
+func NewVFloatFunStub(f SFloatFun, Tc, SigmaMargin float64) *VFloatFunStub {
+	stub := new(VFloatFunStub)
+	stub.SFloatFun = f
+	stub.Tc = Tc
+	stub.SigmaMargin = SigmaMargin
+	return stub
+}
+
+//NewTry creates a try as an VFloatTry
+func (f *VFloatFunStub) NewTry() Try {
+	try := NewVFloatTry(f.DefaultParam(), f.CreateData(), f.Tc)
+	f.IDecode(try.TryData, try.Parameter())
+	try.cost.Set(f.Cost(try.TryData))
+	return try
+}
+
+//SetTry sets try  to a new parameter z measuring the cost once; its variance
+//is known once UpdateCost() adds a second sample
+func (f *VFloatFunStub) SetTry(t Try, z *big.Int) {
+	try := t.(*VFloatTry)
+	try.x.Set(z)
+	f.IDecode(try.TryData, try.Parameter())
+	try.cost.Set(f.Cost(try.TryData))
+}
+
+//Copy copies src to dest
+func (f *VFloatFunStub) Copy(dest, src Try) {
+	d := dest.(*VFloatTry)
+	s := src.(*VFloatTry)
+	d.x.Set(s.x)
+	f.CopyData(d.TryData, s.TryData)
+	d.cost.Copy(s.cost)
+}
+
+//UpdateCost recalculates the try cost
+func (f *VFloatFunStub) UpdateCost(t Try) {
+	try := t.(*VFloatTry)
+	try.cost.Update(f.Cost(try.TryData))
+}
+
+//Sample recalculates the try cost as UpdateCost does and returns the new sample;
+//when restart is true the stats of the cost start afresh from the new sample
+func (f *VFloatFunStub) Sample(t Try, restart bool) float64 {
+	try := t.(*VFloatTry)
+	x := f.Cost(try.TryData)
+	if restart {
+		try.cost.Set(x)
+	} else {
+		try.cost.Update(x)
+	}
+	return x
+}
+
+/*Cmp compares the tries. In CostMode it gives the confidence of the Welch test
+from VFloatCostValue.Cmp; in TriesMode it gives the Welch statistic in units of
+SigmaMargin so a try replaces the Personal-best once it is better by more than
+SigmaMargin standard errors.
+*/
+func (f *VFloatFunStub) Cmp(x, y Try, mode CmpMode) float64 {
+	s := x.(*VFloatTry)
+	t := y.(*VFloatTry)
+	result := t.Cmp(s, mode)
+	if mode == TriesMode {
+		result = result / f.SigmaMargin
+	}
+	return result
+}
+
+// ToConstraint uses the previous try pre and the updating hint parameter
+// to attempt to produce an update to pre which satisfies
+// solution constraints it returns valid = True if succeeds, otherwise pre remains un changed and returns false
+func (f *VFloatFunStub) ToConstraint(pre Try, hint *big.Int) bool {
+	p := pre.(*VFloatTry)
+	if f.Constraint(p.TryData, hint) {
+		f.SetTry(pre, hint)
+		return true
+	}
+	return false
+}
+
+/*VFloatCostValue is the data type used to store noisy Float cost values with
+  an exponentially weighted mean and variance.
+  Each sample has a weight that decays by 1-1/Tc with each later sample, which
+  allows for gradual change to the cost function itself, so the effective
+  number of samples is at most about 2Tc. Two cost values are compared by a
+  Welch test of their means using Student's t distribution with the
+  Welch-Satterthwaite degrees of freedom. */
+type VFloatCostValue struct {
+	// weighted mean of the samples
+	mean float64
+	// weighted sum of squared deviations from the mean
+	s float64
+	// sum of the weights and of their squares
+	w, w2 float64
+	// remembering gain
+	alpha float64
+	// Time constant
+	Tc float64
+}
+
+// NewVFloatCostValue returns a pointer to VFloatCostValue type initialized
+// with a  time constant to stats change of Tc.
+func NewVFloatCostValue(Tc float64) *VFloatCostValue {
+	c := new(VFloatCostValue)
+	c.Tc = Tc
+	c.alpha = 1.0 - 1.0/Tc
+	return c
+}
+
+// Copy takes a copy of c1
+func (c *VFloatCostValue) Copy(c1 *VFloatCostValue) {
+	*c = *c1
+}
+
+//String gives human readable description
+func (c *VFloatCostValue) String() string {
+	lo, hi := c.Interval(0.95)
+	return fmt.Sprintf("mean=%f 95%%interval=[%f,%f] samples=%f TC=%f  \n ",
+		c.mean, lo, hi, c.Samples(), c.Tc)
+}
+
+//Set starts the stats afresh from the sample x
+func (c *VFloatCostValue) Set(x float64) {
+	c.mean = x
+	c.s = 0
+	c.w = 1.0
+	c.w2 = 1.0
+}
+
+//Update adds the sample x to the stats using West's weighted update
+func (c *VFloatCostValue) Update(x float64) {
+	c.w = c.alpha*c.w + 1
+	c.w2 = c.alpha*c.alpha*c.w2 + 1
+	d := x - c.mean
+	c.mean += d / c.w
+	c.s = c.alpha*c.s + d*(x-c.mean)
+}
+
+// Mean returns the weighted mean of the samples.
+func (c *VFloatCostValue) Mean() float64 { return c.mean }
+
+// Samples returns the effective number of samples.
+func (c *VFloatCostValue) Samples() float64 {
+	if c.w2 == 0 {
+		return 0
+	}
+	return c.w * c.w / c.w2
+}
+
+// Variance returns the weighted sample variance and false if it is not known
+// since there is no more than one effective sample.
+func (c *VFloatCostValue) Variance() (float64, bool) {
+	den := c.w - c.w2/c.w
+	if c.w == 0 || den < 1e-9 {
+		return 0, false
+	}
+	return math.Max(c.s, 0) / den, true
+}
+
+// Interval returns the confidence interval of the mean at confidence level,
+// which is unbounded while the variance is not known.
+func (c *VFloatCostValue) Interval(level float64) (lo, hi float64) {
+	v, ok := c.Variance()
+	if !ok {
+		return math.Inf(-1), math.Inf(1)
+	}
+	n := c.Samples()
+	h := TQuantile(0.5+level/2, n-1) * math.Sqrt(v/n)
+	return c.mean - h, c.mean + h
+}
+
+// welch returns the Welch statistic for the mean of c1 being more than that of
+// c, and its degrees of freedom. When the variance of one is not known the
+// variance of the other is used for both; ok is false if neither is known.
+func (c *VFloatCostValue) welch(c1 *VFloatCostValue) (t, df float64, ok bool) {
+	v, okv := c.Variance()
+	v1, okv1 := c1.Variance()
+	n, n1 := c.Samples(), c1.Samples()
+	df0, df1 := n-1, n1-1
+	switch {
+	case !okv && !okv1:
+		return 0, 0, false
+	case !okv:
+		v, df0 = v1, df1
+	case !okv1:
+		v1, df1 = v, df0
+	}
+	a, b := v/n, v1/n1
+	d := c1.mean - c.mean
+	if a+b == 0 {
+		// both are deterministic
+		if d == 0 {
+			return 0, 1, true
+		}
+		return d * math.Inf(1), 1, true
+	}
+	df = (a + b) * (a + b) / (a*a/df0 + b*b/df1)
+	return d / math.Sqrt(a+b), df, true
+}
+
+/*Cmp compares with c1. In CostMode it returns the confidence from the Welch
+test, the probability under Student's t distribution that the statistic is
+smaller in size, with the sign of the difference of c1 mean less c mean, so it
+lies in (-1,1) and is close to 1 when c is confidently better. In TriesMode it
+returns the Welch statistic itself. While the variances are not known it
+returns 0.5 with the sign of the difference as SFloatCostValue does.
+*/
+func (c *VFloatCostValue) Cmp(c1 *VFloatCostValue, mode CmpMode) float64 {
+	t, df, ok := c.welch(c1)
+	if !ok {
+		if c1.mean > c.mean {
+			return 0.5
+		}
+		return -0.5
+	}
+	if mode == TriesMode {
+		return t
+	}
+	if math.IsInf(t, 0) {
+		return math.Copysign(1, t)
+	}
+	return math.Copysign(betaInc(t*t/(df+t*t), 0.5, df/2), t)
+}
+
+// Fbits scales the cost value by taking sign(x.mean)log2(1+|x.mean|)
+func (c *VFloatCostValue) Fbits() float64 {
+	return fbits(c.mean)
+}
+
+// tCDF returns the cumulative probability of t under Student's t distribution
+// with df degrees of freedom.
+func tCDF(t, df float64) float64 {
+	p := 0.5 * betaInc(df/(df+t*t), df/2, 0.5)
+	if t > 0 {
+		return 1 - p
+	}
+	return p
+}
+
+// TQuantile returns the p quantile, p >= 0.5, of Student's t distribution with
+// df degrees of freedom by bisection of its cumulative probability.
+func TQuantile(p, df float64) float64 {
+	lo, hi := 0.0, 1.0
+	for tCDF(hi, df) < p && hi < 1e12 {
+		lo, hi = hi, 2*hi
+	}
+	for i := 0; i < 60; i++ {
+		mid := (lo + hi) / 2
+		if tCDF(mid, df) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// betaInc returns the regularized incomplete beta function I_x(a,b) using the
+// continued fraction of betaCF.
+func betaInc(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+	if x < (a+1)/(a+b+2) {
+		return front * betaCF(x, a, b) / a
+	}
+	return 1 - front*betaCF(1-x, b, a)/b
+}
+
+// betaCF evaluates the continued fraction for the incomplete beta function by
+// the modified Lentz method.
+func betaCF(x, a, b float64) float64 {
+	const tiny = 1e-300
+	const eps = 1e-15
+	guard := func(v float64) float64 {
+		if math.Abs(v) < tiny {
+			return tiny
+		}
+		return v
+	}
+	c := 1.0
+	d := 1 / guard(1-(a+b)*x/(a+1))
+	h := d
+	for m := 1; m <= 300; m++ {
+		fm := float64(m)
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 / guard(1+num*d)
+		c = guard(1 + num/c)
+		h *= d * c
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 / guard(1+num*d)
+		c = guard(1 + num/c)
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < eps {
+			break
+		}
+	}
+	return h
+}
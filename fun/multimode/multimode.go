@@ -71,6 +71,26 @@ Tc -- time constant of statistics in number of cost value updates
 */
 func NewFun(nMode, nbits int, margin, sigma float64,
 	Tc, SigmaMargin float64, fsd int64) *SFloatFunStub {
+	return futil.NewSFloatFunStub(newFun(nMode, nbits, margin, sigma, Tc, SigmaMargin, fsd), Tc, SigmaMargin)
+}
+
+//VFloatFunStub gives interface to setpso with variance-aware costs
+type VFloatFunStub = futil.VFloatFunStub
+
+/*NewVFun creates Fun as NewFun does but its costs use
+futil.VFloatCostValue, which compares costs by a Welch test of their means, so
+needs:
+Tc -- time constant of statistics in number of cost value updates
+SigmaMargin -- margin in standard errors for a try on the tries list to be better
+*/
+func NewVFun(nMode, nbits int, margin, sigma float64,
+	Tc, SigmaMargin float64, fsd int64) *VFloatFunStub {
+	return futil.NewVFloatFunStub(newFun(nMode, nbits, margin, sigma, Tc, SigmaMargin, fsd), Tc, SigmaMargin)
+}
+
+// newFun returns the *Fun used by NewFun and NewVFun.
+func newFun(nMode, nbits int, margin, sigma float64,
+	Tc, SigmaMargin float64, fsd int64) *Fun {
 	f := new(Fun)
 	f.rnd = rand.New(rand.NewSource(fsd))
 	f.nMode = nMode
@@ -86,7 +106,7 @@ func NewFun(nMode, nbits int, margin, sigma float64,
 	max := 1 << uint(f.nbits)
 	f.factor = 1.0 / float64(max)
 	f.bestX = math.Acos(a) / f.omega
-	return futil.NewSFloatFunStub(f, Tc, SigmaMargin)
+	return f
 }
 
 //CreateData creates a empty structure for decoded try
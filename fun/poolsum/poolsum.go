@@ -0,0 +1,187 @@
+/*
+Package poolsum contains the object Fun and its generator for a subset sum
+cost function that supports item replacement through Delete(). The set items
+are slots that each hold a value taken from a larger pool of values and the
+target is the sum of a subset of the pool, which may need values that are not
+in the slots. When the SPSO finds a slot is not being used Delete() swaps its
+value for the next unused value in the pool so the swarm gets to try out the
+whole pool.
+*/
+package poolsum
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+// Fun is the pool subset sum problem cost function
+type Fun struct {
+	// Target is the subset sum to look for
+	Target *big.Int
+	// Pool is the array of values the slots can take
+	Pool []*big.Int
+	// Slots gives the index in Pool of the value held by each slot where
+	// the ith slot corresponds to the ith bit of the big integer
+	// representing the subset
+	Slots []int
+	// inSlot is true for pool values that are in a slot
+	inSlot []bool
+	// next pool index to look at for a replacement value
+	next int
+	// targetS is a solution subset of the pool possibly among many
+	targetS *big.Int
+	// NBit is the number of bits used to give the pool values
+	NBit int
+	// Seed used for generating the problem
+	Seed int64
+	// number of slot values replaced
+	nreplaced int
+}
+
+// Try is the try interface used by setpso
+type Try = setpso.Try
+
+// TryData is the interface for FunTryData used in package futil
+type TryData = futil.TryData
+
+// FunTryData is the decoded data structure for a try
+type FunTryData struct {
+	subset *big.Int
+}
+
+// IDecode decodes z into data
+func (f *Fun) IDecode(data TryData, z *big.Int) {
+	data.(*FunTryData).subset.Set(z)
+}
+
+// Decode gives the subset of slots in binary
+func (d *FunTryData) Decode() string {
+	return d.subset.Text(2)
+}
+
+// IntFunStub gives interface to setpso
+type IntFunStub = futil.IntFunStub
+
+/*
+New generates a pool subset sum problem with nSlot slots and a pool of nPool
+values using nBit bits to represent the values with the random number generator
+seed sd. The slots start with the first nSlot values of the pool.
+*/
+func New(nSlot, nPool, nBit int, sd int64) *IntFunStub {
+	var f Fun
+	f.Seed = sd
+	f.NBit = nBit
+	if nPool < nSlot {
+		nPool = nSlot
+	}
+	f.Pool = make([]*big.Int, nPool)
+	rnd := rand.New(rand.NewSource(sd))
+	maxVal := big.NewInt(0)
+	maxVal.SetBit(maxVal, nBit, 1)
+	maxVal.Sub(maxVal, big.NewInt(1))
+	for i := range f.Pool {
+		f.Pool[i] = new(big.Int).Rand(rnd, maxVal)
+	}
+	f.Slots = make([]int, nSlot)
+	f.inSlot = make([]bool, nPool)
+	for i := range f.Slots {
+		f.Slots[i] = i
+		f.inSlot[i] = true
+	}
+	f.next = nSlot % nPool
+	// choose the target from a subset of the whole pool
+	n := rnd.Intn(nSlot) + 1
+	f.Target = big.NewInt(0)
+	f.targetS = big.NewInt(0)
+	for i := 0; i < n; i++ {
+		j := rnd.Intn(nPool)
+		if f.targetS.Bit(j) == 0 {
+			f.targetS.SetBit(f.targetS, j, 1)
+			f.Target.Add(f.Target, f.Pool[j])
+		}
+	}
+	return futil.NewIntFunStub(&f)
+}
+
+// CreateData creates a empty structure for decoded try
+func (f *Fun) CreateData() TryData {
+	t := new(FunTryData)
+	t.subset = new(big.Int)
+	return t
+}
+
+// Cost calculates the cost as the absolute value of the difference between
+// the sum of the slot values in the subset and the target value
+func (f *Fun) Cost(data TryData, cost *big.Int) {
+	x := data.(*FunTryData).subset
+	cost.SetInt64(0)
+	for i, k := range f.Slots {
+		if x.Bit(i) == 1 {
+			cost.Add(cost, f.Pool[k])
+		}
+	}
+	cost.Abs(cost.Sub(cost, f.Target))
+}
+
+// DefaultParam gives a default that satisfies constraints
+func (f *Fun) DefaultParam() *big.Int {
+	return new(big.Int)
+}
+
+// CopyData copies src to dest
+func (f *Fun) CopyData(dest, src TryData) {
+	dest.(*FunTryData).subset.Set(src.(*FunTryData).subset)
+}
+
+// MaxLen returns the number of slots
+func (f *Fun) MaxLen() int {
+	return len(f.Slots)
+}
+
+// Constraint accepts any subset of the slots
+func (f *Fun) Constraint(pre TryData, hint *big.Int) (valid bool) {
+	return true
+}
+
+/*
+Delete replaces the value in the ith slot by the next pool value that is not in
+a slot, looking through the pool in turn. It returns false if all the pool
+values are in slots. The replacement only depends on earlier calls so instances
+created with the same arguments make the same replacements.
+*/
+func (f *Fun) Delete(i int) bool {
+	for k := 0; k < len(f.Pool); k++ {
+		j := (f.next + k) % len(f.Pool)
+		if !f.inSlot[j] {
+			f.inSlot[f.Slots[i]] = false
+			f.inSlot[j] = true
+			f.Slots[i] = j
+			f.next = (j + 1) % len(f.Pool)
+			f.nreplaced++
+			return true
+		}
+	}
+	return false
+}
+
+// Replaced returns the number of slot values replaced.
+func (f *Fun) Replaced() int { return f.nreplaced }
+
+// About returns a string description of the contents of Fun
+func (f *Fun) About() string {
+	s := "pool subset sum problem parameters:\n"
+	s += fmt.Sprintf("nSlots= %d nPool= %d NBit = %d Seed= %v\n",
+		len(f.Slots), len(f.Pool), f.NBit, f.Seed)
+	s += fmt.Sprintf("Target value: %v\n", f.Target)
+	s += "pool solution:\n"
+	s += fmt.Sprintf("%s\n", f.targetS.Text(2))
+	s += fmt.Sprintf("slot values after %d replacements:\n", f.nreplaced)
+	for i, k := range f.Slots {
+		s += fmt.Sprintf("%d \t %v (pool %d)\n", i, f.Pool[k], k)
+	}
+	return s
+}
@@ -0,0 +1,31 @@
+package poolsum
+
+import (
+	"fmt"
+	"math/big"
+)
+
+func ExampleFun_Delete() {
+	f := New(4, 6, 10, 3142)
+	try := f.NewTry()
+	f.SetTry(try, big.NewInt(5))
+	fmt.Printf("Cost = %v\n", try.Cost())
+	f.Delete(0)
+	f.Delete(2)
+	f.UpdateCost(try)
+	fmt.Print(f.About())
+	fmt.Printf("Cost = %v\n", try.Cost())
+	//Output:
+	//Cost = 543
+	//pool subset sum problem parameters:
+	//nSlots= 4 nPool= 6 NBit = 10 Seed= 3142
+	//Target value: 1041
+	//pool solution:
+	//101001
+	//slot values after 2 replacements:
+	//0 	 172 (pool 4)
+	//1 	 133 (pool 1)
+	//2 	 158 (pool 5)
+	//3 	 762 (pool 3)
+	//Cost = 711
+}
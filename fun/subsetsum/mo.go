@@ -0,0 +1,45 @@
+package subsetsum
+
+import (
+	"math/big"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+/*
+MOFun is a two objective version of the subset sum problem that trades off the
+absolute value of the difference between the subset sum and the target value
+against the number of elements in the subset.
+*/
+type MOFun struct {
+	*Fun
+	// scratch pad for the subset sum
+	sum *big.Int
+}
+
+//VecFunStub gives interface to setpso
+type VecFunStub = futil.VecFunStub
+
+// NewMO generates a two objective subset sum problem with the same elements
+// and target as New() for the same arguments.
+func NewMO(nElement int, nBit int, sd int64) *VecFunStub {
+	return futil.NewVecFunStub(&MOFun{newFun(nElement, nBit, sd), new(big.Int)})
+}
+
+// NObjectives returns the number of objectives, which is 2.
+func (f *MOFun) NObjectives() int { return 2 }
+
+// Cost puts the absolute value of the difference between the subset sum and
+// the target value into cost[0] and the number of elements into cost[1].
+func (f *MOFun) Cost(data TryData, cost []float64) {
+	f.Fun.Cost(data, f.sum)
+	cost[0], _ = new(big.Float).SetInt(f.sum).Float64()
+	x := data.(*FunTryData).subset
+	n := 0
+	for i := range f.ElementValues {
+		if x.Bit(i) == 1 {
+			n++
+		}
+	}
+	cost[1] = float64(n)
+}
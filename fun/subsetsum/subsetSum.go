@@ -61,6 +61,11 @@ type IntFunStub = futil.IntFunStub
 // nBit bits to represent the element values using the random number
 // generator seed sd , where all element values are taken to be non negative
 func New(nElement int, nBit int, sd int64) *IntFunStub {
+	return futil.NewIntFunStub(newFun(nElement, nBit, sd))
+}
+
+// newFun generates the subset sum problem given by New.
+func newFun(nElement int, nBit int, sd int64) *Fun {
 	var f Fun
 	f.Seed = sd
 	f.NBit = nBit
@@ -85,7 +90,7 @@ func New(nElement int, nBit int, sd int64) *IntFunStub {
 		}
 	}
 
-	return futil.NewIntFunStub(&f)
+	return &f
 }
 
 //CreateData creates a empty structure for decoded try
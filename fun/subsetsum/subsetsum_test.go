@@ -37,3 +37,13 @@ func ExampleNew() {
 	//x= 56789
 	//constrained x= 5555
 }
+
+func ExampleNewMO() {
+	f := NewMO(8, 10, 3142)
+	// 172 = 10101100
+	try := f.NewTry()
+	f.SetTry(try, big.NewInt(172))
+	fmt.Printf("Cost = %v\n", try.Cost())
+	//Output:
+	//Cost =  [121.000000 4.000000]
+}
@@ -0,0 +1,233 @@
+package setpso
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sort"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+/*
+Initializer is the interface to an initialization strategy used by NewPsoInit()
+to choose the initial Parameters of the particles. Init should set the current
+try of each particle to one that satisfies the cost function constraints using
+SetInitial() and return an error if it cannot. The Personal-best of each
+particle is set to its current try after Init returns.
+*/
+type Initializer interface {
+	Init(pso *Pso, rnd *rand.Rand) error
+	// About gives a description of the strategy.
+	About() string
+}
+
+/*
+SetInitial sets the current try of the ith particle from hint using the cost
+function's ToConstraint() and returns true on success; on failure the current
+try is unchanged.
+*/
+func (pso *Pso) SetInitial(i int, hint *big.Int) bool {
+	return pso.fun.ToConstraint(pso.Pt[i].current, hint)
+}
+
+// sample sets t to a random try that satisfies the constraints making at most
+// budget attempts, or as many as needed when budget <= 0. It returns false if
+// it fails in which case t is unchanged. hint is used as a scratch pad.
+func (pso *Pso) sample(t Try, hint *big.Int, rnd *rand.Rand, budget int) bool {
+	for k := 0; budget <= 0 || k < budget; k++ {
+		hint.Rand(rnd, pso.maxN)
+		if pso.fun.ToConstraint(t, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// restInitializer is implemented by the Initializers of this package so that
+// SeededInit can leave the seeded particles alone. initFrom initializes the
+// particles from first on as Init does for all of them.
+type restInitializer interface {
+	initFrom(pso *Pso, rnd *rand.Rand, first int) error
+}
+
+// budgetError gives the error for a particle that failed to satisfy the
+// constraints.
+func budgetError(i, budget int) error {
+	return fmt.Errorf("particle %d did not satisfy the constraints after %d attempts", i, budget)
+}
+
+/*
+RandomInit chooses Parameters uniformly at random, trying again until the
+constraints are satisfied. When Budget > 0 at most Budget attempts are made for
+each particle after which the particle keeps the default try given by the cost
+function's NewTry() if Fallback is true, otherwise Init returns an error. With
+Budget <= 0 it keeps trying, which is what NewPso() does.
+*/
+type RandomInit struct {
+	Budget   int
+	Fallback bool
+}
+
+// Init chooses random initial Parameters.
+func (s *RandomInit) Init(pso *Pso, rnd *rand.Rand) error {
+	return s.initFrom(pso, rnd, 0)
+}
+
+func (s *RandomInit) initFrom(pso *Pso, rnd *rand.Rand, first int) error {
+	for i := first; i < len(pso.Pt); i++ {
+		p := &pso.Pt[i]
+		if !pso.sample(p.current, p.hint, rnd, s.Budget) && !s.Fallback {
+			return budgetError(i, s.Budget)
+		}
+	}
+	return nil
+}
+
+// About gives a description of the strategy.
+func (s *RandomInit) About() string {
+	return fmt.Sprintf("random with budget %d and fallback %t", s.Budget, s.Fallback)
+}
+
+/*
+SeededInit starts the first particles from the user supplied Seeds, one each,
+and uses Rest to initialize the others. Seeds that do not satisfy the
+constraints give an error. The Initializers of this package only initialize
+the particles that are not seeded, and MaximinInit spreads them out from the
+seeds too; any other Rest initializes every particle before the seeds replace
+the first.
+*/
+type SeededInit struct {
+	Seeds []*big.Int
+	Rest  Initializer
+}
+
+// Init sets particles from the seeds and uses Rest for the others.
+func (s *SeededInit) Init(pso *Pso, rnd *rand.Rand) error {
+	r, ok := s.Rest.(restInitializer)
+	if !ok {
+		if err := s.Rest.Init(pso, rnd); err != nil {
+			return err
+		}
+	}
+	n := len(s.Seeds)
+	if n > len(pso.Pt) {
+		n = len(pso.Pt)
+	}
+	for i := 0; i < n; i++ {
+		pso.Pt[i].hint.Set(s.Seeds[i])
+		if !pso.SetInitial(i, pso.Pt[i].hint) {
+			return fmt.Errorf("seed %d does not satisfy the constraints", i)
+		}
+	}
+	if ok {
+		return r.initFrom(pso, rnd, n)
+	}
+	return nil
+}
+
+// About gives a description of the strategy.
+func (s *SeededInit) About() string {
+	return fmt.Sprintf("%d seeds with the rest %s", len(s.Seeds), s.Rest.About())
+}
+
+/*
+OppositionInit uses opposition based initialization. For each particle a random
+constraint satisfying try and its opposite, which has every bit flipped, are
+made and the best half of all these tries are used as the initial Parameters.
+Opposites that do not satisfy the constraints are left out. Budget and Fallback
+are used for the random tries as in RandomInit.
+*/
+type OppositionInit struct {
+	Budget   int
+	Fallback bool
+}
+
+// Init chooses the best of the random tries and their opposites.
+func (s *OppositionInit) Init(pso *Pso, rnd *rand.Rand) error {
+	return s.initFrom(pso, rnd, 0)
+}
+
+func (s *OppositionInit) initFrom(pso *Pso, rnd *rand.Rand, first int) error {
+	ones := new(big.Int).Sub(pso.maxN, big.NewInt(1))
+	hint := new(big.Int)
+	var cand []Try
+	for i := first; i < len(pso.Pt); i++ {
+		t := pso.fun.NewTry()
+		if !pso.sample(t, hint, rnd, s.Budget) && !s.Fallback {
+			return budgetError(i, s.Budget)
+		}
+		cand = append(cand, t)
+		o := pso.fun.NewTry()
+		hint.Xor(t.Parameter(), ones)
+		if pso.fun.ToConstraint(o, hint) {
+			cand = append(cand, o)
+		}
+	}
+	sort.SliceStable(cand, func(a, b int) bool {
+		return pso.fun.Cmp(cand[b], cand[a], futil.CostMode) > 0.0
+	})
+	for i := first; i < len(pso.Pt); i++ {
+		pso.fun.Copy(pso.Pt[i].current, cand[i-first])
+	}
+	return nil
+}
+
+// About gives a description of the strategy.
+func (s *OppositionInit) About() string {
+	return fmt.Sprintf("opposition based with budget %d and fallback %t", s.Budget, s.Fallback)
+}
+
+/*
+MaximinInit spreads out the particles by choosing each in turn from Candidates
+random constraint satisfying tries as the one with the greatest Hamming
+distance to the nearest particle already chosen. Budget and Fallback are used
+for the random tries as in RandomInit.
+*/
+type MaximinInit struct {
+	Candidates int
+	Budget     int
+	Fallback   bool
+}
+
+// Init chooses spread out initial Parameters.
+func (s *MaximinInit) Init(pso *Pso, rnd *rand.Rand) error {
+	return s.initFrom(pso, rnd, 0)
+}
+
+func (s *MaximinInit) initFrom(pso *Pso, rnd *rand.Rand, first int) error {
+	t := pso.fun.NewTry()
+	hint := new(big.Int)
+	diff := new(big.Int)
+	for i := first; i < len(pso.Pt); i++ {
+		best := -1
+		for c := 0; c < s.Candidates || c == 0; c++ {
+			if !pso.sample(t, hint, rnd, s.Budget) {
+				if !s.Fallback {
+					return budgetError(i, s.Budget)
+				}
+				continue
+			}
+			d := pso.maxLen + 1
+			for j := 0; j < i; j++ {
+				diff.Xor(t.Parameter(), pso.Pt[j].current.Parameter())
+				if h := CardinalSize(diff); h < d {
+					d = h
+				}
+			}
+			if d > best {
+				best = d
+				pso.fun.Copy(pso.Pt[i].current, t)
+			}
+		}
+		// when no candidate satisfies the constraints the particle keeps
+		// the default try
+	}
+	return nil
+}
+
+// About gives a description of the strategy.
+func (s *MaximinInit) About() string {
+	return fmt.Sprintf("maximin Hamming distance from %d candidates with budget %d and fallback %t",
+		s.Candidates, s.Budget, s.Fallback)
+}
@@ -0,0 +1,99 @@
+package setpso_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/futil"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+// tightFun only accepts Parameters with at most two items so random
+// Parameters rarely satisfy its constraints.
+type tightFun struct {
+	setpso.Fun
+}
+
+func (f *tightFun) ToConstraint(pre setpso.Try, hint *big.Int) bool {
+	if setpso.CardinalSize(hint) > 2 {
+		return false
+	}
+	return f.Fun.ToConstraint(pre, hint)
+}
+
+// minDistance returns the least Hamming distance between the particles of a
+// swarm with 100 bit Parameters.
+func minDistance(pso *setpso.Pso) int {
+	d := 101
+	diff := new(big.Int)
+	for i := 0; i < pso.Nparticles(); i++ {
+		for j := 0; j < i; j++ {
+			diff.Xor(pso.CurrentTry(i).Parameter(), pso.CurrentTry(j).Parameter())
+			if h := setpso.CardinalSize(diff); h < d {
+				d = h
+			}
+		}
+	}
+	return d
+}
+
+func TestInit(t *testing.T) {
+	f := &tightFun{subsetsum.New(100, 20, 3142)}
+	if _, err := setpso.NewPsoInit(10, f, 578, &setpso.RandomInit{Budget: 20}); err == nil {
+		t.Errorf("exhausted attempt budget did not give an error")
+	}
+	pso, err := setpso.NewPsoInit(10, f, 578, &setpso.RandomInit{Budget: 20, Fallback: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < pso.Nparticles(); i++ {
+		if pso.CurrentTry(i).Parameter().Sign() != 0 {
+			t.Errorf("particle %d did not fall back to the default", i)
+		}
+	}
+	seeds := []*big.Int{big.NewInt(1), big.NewInt(6)}
+	pso, err = setpso.NewPsoInit(10, f, 578, &setpso.SeededInit{Seeds: seeds,
+		Rest: &setpso.RandomInit{Budget: 20, Fallback: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range seeds {
+		if pso.LocalBestTry(i).Parameter().Cmp(x) != 0 {
+			t.Errorf("particle %d does not start from its seed", i)
+		}
+	}
+	// only the particles that are not seeded are initialized by Rest
+	c := new(setpso.EvalCounter)
+	if _, err = setpso.NewPsoInit(10, setpso.NewCountingFun(subsetsum.New(100, 20, 3142), c), 578,
+		&setpso.SeededInit{Seeds: seeds, Rest: &setpso.RandomInit{}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, n := c.Counts(); n != 10 {
+		t.Errorf("seeded initialization of 10 particles made %d constraint calls", n)
+	}
+	if _, err = setpso.NewPsoInit(10, f, 578, &setpso.SeededInit{Seeds: []*big.Int{big.NewInt(7)},
+		Rest: &setpso.RandomInit{Budget: 20, Fallback: true}}); err == nil {
+		t.Errorf("seed not satisfying the constraints did not give an error")
+	}
+
+	g := subsetsum.New(100, 20, 3142)
+	random := setpso.NewPso(10, g, 578)
+	maximin, err := setpso.NewPsoInit(10, g, 578, &setpso.MaximinInit{Candidates: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if minDistance(maximin) <= minDistance(random) {
+		t.Errorf("maximin spread %d is no better than random spread %d",
+			minDistance(maximin), minDistance(random))
+	}
+	opp, err := setpso.NewPsoInit(10, g, 578, &setpso.OppositionInit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < opp.Nparticles(); i++ {
+		if g.Cmp(opp.CurrentTry(i), opp.CurrentTry(i-1), futil.CostMode) < 0.0 {
+			t.Errorf("opposition particles are not best first")
+		}
+	}
+}
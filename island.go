@@ -0,0 +1,288 @@
+package setpso
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+/*
+Island is the interface to a SPSO used as an island of Islands. All the SPSOs
+in this package satisfy it, with Base() coming from Pso.
+*/
+type Island interface {
+	PsoInterface
+	// Base returns the Pso the SPSO is built on.
+	Base() *Pso
+}
+
+/*
+Islands is a multi-swarm optimizer made up of several SPSOs, the islands, each
+with its own particles, which can be of different variants and have different
+heuristics. Every Period updates each island receives copies of the
+Personal-best Parameters of the best NMigrants particles of each of its
+neighbours in a neighbourhood graph given by a Topology; these replace the
+worst particles of the island. The islands should use the same cost function
+but when Concurrent is true the islands are updated on separate goroutines so
+each must have its own instance of the cost function. Item replacement, set up
+by SetItemReplacement(), is turned off for the islands since an item replaced
+on one island would change the meaning of the Parameters migrating from the
+others.
+
+Islands satisfies PsoInterface by numbering the particles of the islands one
+after the other.
+*/
+type Islands struct {
+	islands []Island
+	top     Topology
+	// migration gap in updates
+	Period int
+	// number of particles sent to each neighbour on migration
+	NMigrants int
+	// true when islands are updated on separate goroutines
+	Concurrent bool
+	// neighbours of each island
+	nb [][]int
+	// particle number offset of each island
+	offset []int
+	// source of rnd which keeps track of the generator position
+	src *countedSource
+	rnd *rand.Rand
+	// update count
+	iter int
+	// number of migrations so far
+	migrations int
+}
+
+/*
+NewIslands creates Islands from the islands linked by the neighbourhood graph
+top with migration every period updates of nmigrants particles. sd seeds the
+random number generator used for random graphs. It turns off item replacement
+for each island.
+*/
+func NewIslands(top Topology, period, nmigrants int, sd int64, islands ...Island) *Islands {
+	is := &Islands{islands: islands, top: top, Period: period, NMigrants: nmigrants}
+	is.src = newCountedSource(sd)
+	is.rnd = rand.New(is.src)
+	is.nb = make([][]int, len(islands))
+	is.offset = make([]int, len(islands)+1)
+	for k, p := range islands {
+		is.offset[k+1] = is.offset[k] + p.Nparticles()
+		p.Base().SetItemReplacement(0, 0)
+	}
+	is.top.Wire(is.nb, is.rnd)
+	return is
+}
+
+// Island returns the kth island.
+func (is *Islands) Island(k int) Island { return is.islands[k] }
+
+// Nislands returns the number of islands.
+func (is *Islands) Nislands() int { return len(is.islands) }
+
+// Neighbours returns the islands that send migrants to the kth island.
+func (is *Islands) Neighbours(k int) []int { return is.nb[k] }
+
+// Topology returns the neighbourhood graph of the islands.
+func (is *Islands) Topology() Topology { return is.top }
+
+// locate returns the island and its particle number for the ith particle.
+func (is *Islands) locate(i int) (k, j int) {
+	k = sort.SearchInts(is.offset, i+1) - 1
+	return k, i - is.offset[k]
+}
+
+/*
+Update updates each island, on separate goroutines if Concurrent is true, and
+then does a migration every Period updates.
+*/
+func (is *Islands) Update() {
+	if is.Concurrent {
+		var wg sync.WaitGroup
+		for _, p := range is.islands {
+			wg.Add(1)
+			go func(p Island) {
+				defer wg.Done()
+				p.Update()
+			}(p)
+		}
+		wg.Wait()
+	} else {
+		for _, p := range is.islands {
+			p.Update()
+		}
+	}
+	is.iter++
+	if is.Period > 0 && is.iter%is.Period == 0 {
+		is.Migrate()
+	}
+}
+
+// bestFirst returns the particle numbers of pso sorted with the best
+// Personal-best first.
+func bestFirst(pso *Pso) []int {
+	order := make([]int, pso.Nparticles())
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return pso.fun.Cmp(pso.Pt[order[b]].bestTry, pso.Pt[order[a]].bestTry, futil.CostMode) > 0.0
+	})
+	return order
+}
+
+/*
+Migrate sends copies of the Personal-best Parameters of the best NMigrants
+particles of each island to its neighbours where they replace the worst
+particles. An island always keeps its best particle. The migrants are chosen
+before any are replaced so the result does not depend on the order of the
+islands. The neighbourhood graph is rewired first if the Topology asks for it
+where the iteration count given to the Topology is the number of migrations.
+*/
+func (is *Islands) Migrate() {
+	if is.top.Rewire(is.migrations) {
+		is.top.Wire(is.nb, is.rnd)
+	}
+	is.migrations++
+	migrants := make([][]*big.Int, len(is.islands))
+	orders := make([][]int, len(is.islands))
+	for k, p := range is.islands {
+		pso := p.Base()
+		orders[k] = bestFirst(pso)
+		for _, i := range orders[k] {
+			if len(migrants[k]) == is.NMigrants {
+				break
+			}
+			migrants[k] = append(migrants[k], new(big.Int).Set(pso.Pt[i].bestTry.Parameter()))
+		}
+	}
+	for k, p := range is.islands {
+		pso := p.Base()
+		worst := len(orders[k]) - 1
+		for _, j := range is.nb[k] {
+			for _, x := range migrants[j] {
+				if worst < 1 {
+					break
+				}
+				pso.SetParticle(orders[k][worst], x)
+				worst--
+			}
+		}
+		pso.UpdateGlobal()
+	}
+}
+
+// BestParticle returns the best particle over all islands.
+func (is *Islands) BestParticle() int {
+	best := 0
+	for k, p := range is.islands {
+		i := p.BestParticle()
+		if k == 0 || p.Base().fun.Cmp(is.LocalBestTry(best), p.LocalBestTry(i), futil.CostMode) > 0.0 {
+			best = is.offset[k] + i
+		}
+	}
+	return best
+}
+
+// Nparticles returns the total number of particles over all islands.
+func (is *Islands) Nparticles() int { return is.offset[len(is.islands)] }
+
+// Part returns the ith particle.
+func (is *Islands) Part(i int) *Particle {
+	k, j := is.locate(i)
+	return is.islands[k].Part(j)
+}
+
+// CurrentTry returns the current try of the ith particle.
+func (is *Islands) CurrentTry(i int) Try {
+	k, j := is.locate(i)
+	return is.islands[k].CurrentTry(j)
+}
+
+// LocalBestTry returns the Personal-best try of the ith particle.
+func (is *Islands) LocalBestTry(i int) Try {
+	k, j := is.locate(i)
+	return is.islands[k].LocalBestTry(j)
+}
+
+// PrintDebug outputs the debugging diagnostics of each island in turn.
+func (is *Islands) PrintDebug(w io.Writer, cmd string) {
+	for k, p := range is.islands {
+		fmt.Fprintf(w, "island %d:\n", k)
+		p.PrintDebug(w, cmd)
+	}
+}
+
+// Heuristics returns the master heuristics of the first island.
+func (is *Islands) Heuristics() *PsoHeuristics { return is.islands[0].Heuristics() }
+
+// SetHeuristics sets the heuristics of all the islands.
+func (is *Islands) SetHeuristics(hu *PsoHeuristics) {
+	for _, p := range is.islands {
+		p.SetHeuristics(hu)
+	}
+}
+
+// islandsState is the stored form of Islands.
+type islandsState struct {
+	Seed       int64
+	RandCount  uint64
+	RandState  []uint64
+	Iter       int
+	Migrations int
+	Neighbours [][]int
+	Swarms     [][]byte
+}
+
+// Checkpoint writes the state of the Islands to w. Each island must be a
+// Checkpointer.
+func (is *Islands) Checkpoint(w io.Writer) error {
+	st := islandsState{Seed: is.src.seed, RandCount: is.src.count, RandState: is.src.state(),
+		Iter: is.iter, Migrations: is.migrations, Neighbours: is.nb}
+	for k, p := range is.islands {
+		c, ok := p.(Checkpointer)
+		if !ok {
+			return fmt.Errorf("island %d does not support checkpoints", k)
+		}
+		var buf bytes.Buffer
+		if err := c.Checkpoint(&buf); err != nil {
+			return err
+		}
+		st.Swarms = append(st.Swarms, buf.Bytes())
+	}
+	return gob.NewEncoder(w).Encode(&st)
+}
+
+// Restore reads a state written by Checkpoint() from r and replaces the state
+// of the Islands by it.
+func (is *Islands) Restore(r io.Reader) error {
+	var st islandsState
+	if err := gob.NewDecoder(r).Decode(&st); err != nil {
+		return err
+	}
+	if len(st.Swarms) != len(is.islands) || len(st.Neighbours) != len(is.islands) {
+		return fmt.Errorf("checkpoint was not made by %d islands", len(is.islands))
+	}
+	for k, p := range is.islands {
+		c, ok := p.(Checkpointer)
+		if !ok {
+			return fmt.Errorf("island %d does not support checkpoints", k)
+		}
+		if err := c.Restore(bytes.NewReader(st.Swarms[k])); err != nil {
+			return fmt.Errorf("island %d: %v", k, err)
+		}
+	}
+	for k := range is.nb {
+		is.nb[k] = append(is.nb[k][:0], st.Neighbours[k]...)
+	}
+	is.iter = st.Iter
+	is.migrations = st.Migrations
+	return is.src.setState(st.Seed, st.RandCount, st.RandState)
+}
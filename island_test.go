@@ -0,0 +1,67 @@
+package setpso_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/futil"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+func TestIslands(t *testing.T) {
+	newIslands := func(concurrent bool) *setpso.Islands {
+		var islands []setpso.Island
+		for k := 0; k < 3; k++ {
+			p := setpso.NewPso(8, subsetsum.New(100, 20, 3142), 578+int64(k))
+			if k == 1 {
+				islands = append(islands, setpso.NewCLPso(p))
+			} else {
+				islands = append(islands, setpso.NewGPso(p))
+			}
+		}
+		is := setpso.NewIslands(&setpso.RingTopology{K: 1}, 5, 2, 99, islands...)
+		is.Concurrent = concurrent
+		return is
+	}
+	serial := newIslands(false)
+	concurrent := newIslands(true)
+	if serial.Nparticles() != 24 {
+		t.Fatalf("islands have %d particles want 24", serial.Nparticles())
+	}
+	for i := 0; i < 30; i++ {
+		serial.Update()
+		concurrent.Update()
+	}
+	for i := 0; i < serial.Nparticles(); i++ {
+		if serial.LocalBestTry(i).Parameter().Cmp(concurrent.LocalBestTry(i).Parameter()) != 0 {
+			t.Errorf("particle %d differs between serial and concurrent islands", i)
+		}
+	}
+	f := subsetsum.New(100, 20, 3142)
+	best := serial.LocalBestTry(serial.BestParticle())
+	for k := 0; k < serial.Nislands(); k++ {
+		p := serial.Island(k)
+		if f.Cmp(p.LocalBestTry(p.BestParticle()), best, futil.CostMode) < 0.0 {
+			t.Errorf("island %d has a better particle than the best particle", k)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := serial.Checkpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored := newIslands(false)
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 12; i++ {
+		serial.Update()
+		restored.Update()
+	}
+	for i := 0; i < serial.Nparticles(); i++ {
+		if serial.LocalBestTry(i).Parameter().Cmp(restored.LocalBestTry(i).Parameter()) != 0 {
+			t.Errorf("particle %d differs after restoring islands", i)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package setpso
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+/*
+LocalSearcher is the interface to a local search used by SetLocalSearch() to
+make the last few bit flips that an SPSO struggles to find. Search should try
+to improve t, which satisfies the constraints, by searching near its
+Parameters, whose items are numbered from 0 to maxLen-1, replacing t by the
+best try found and returning true if it is better. It should only make tries
+using f's ToConstraint(), which sets valid tries as SetTry() does, and compare
+them using f's Cmp() so that it works on any cost-function; NewTry() and Copy()
+may be used for scratch tries.
+*/
+type LocalSearcher interface {
+	Search(f Fun, t Try, maxLen int, rnd *rand.Rand) bool
+	// About gives a description of the search.
+	About() string
+}
+
+// move sets c to the constraint satisfying try from the Parameters of t with
+// the items in flip flipped and returns true if it is better than t. hint is
+// used as a scratch pad.
+func move(f Fun, c, t Try, hint *big.Int, flip ...int) bool {
+	hint.Set(t.Parameter())
+	for _, i := range flip {
+		hint.SetBit(hint, i, hint.Bit(i)^1)
+	}
+	f.Copy(c, t)
+	return f.ToConstraint(c, hint) && f.Cmp(c, t, futil.CostMode) < 0.0
+}
+
+/*
+BitFlipSearch is a first-improvement single bit flip search. Each pass flips
+the items one at a time in a random order and keeps each flip that gives an
+improvement. It stops after a pass with no improvement or after MaxPasses
+passes when MaxPasses > 0.
+*/
+type BitFlipSearch struct {
+	MaxPasses int
+}
+
+// Search improves t by single bit flips.
+func (s *BitFlipSearch) Search(f Fun, t Try, maxLen int, rnd *rand.Rand) bool {
+	c := f.NewTry()
+	hint := new(big.Int)
+	improved := false
+	for pass := 0; s.MaxPasses <= 0 || pass < s.MaxPasses; pass++ {
+		better := false
+		for _, i := range rnd.Perm(maxLen) {
+			if move(f, c, t, hint, i) {
+				f.Copy(t, c)
+				better = true
+			}
+		}
+		if !better {
+			break
+		}
+		improved = true
+	}
+	return improved
+}
+
+// About gives a description of the search.
+func (s *BitFlipSearch) About() string {
+	return fmt.Sprintf("first-improvement bit flip with at most %d passes", s.MaxPasses)
+}
+
+/*
+TwoFlipSearch is a best-improvement two bit flip search, which suits problems
+such as subset-sum where an item is often best swapped for another. Each pass
+tries flipping every pair of items, or Sample pairs chosen at random when
+Sample > 0, and makes the best flip if it gives an improvement. It stops after a
+pass with no improvement or after MaxPasses passes when MaxPasses > 0.
+*/
+type TwoFlipSearch struct {
+	MaxPasses int
+	Sample    int
+}
+
+// Search improves t by pairs of bit flips.
+func (s *TwoFlipSearch) Search(f Fun, t Try, maxLen int, rnd *rand.Rand) bool {
+	if maxLen < 2 {
+		return false
+	}
+	c := f.NewTry()
+	best := f.NewTry()
+	hint := new(big.Int)
+	improved := false
+	for pass := 0; s.MaxPasses <= 0 || pass < s.MaxPasses; pass++ {
+		better := false
+		try := func(i, j int) {
+			if move(f, c, t, hint, i, j) && (!better || f.Cmp(c, best, futil.CostMode) < 0.0) {
+				f.Copy(best, c)
+				better = true
+			}
+		}
+		if s.Sample > 0 {
+			for k := 0; k < s.Sample; k++ {
+				i := rnd.Intn(maxLen)
+				j := rnd.Intn(maxLen - 1)
+				if j >= i {
+					j++
+				}
+				try(i, j)
+			}
+		} else {
+			for i := 0; i < maxLen; i++ {
+				for j := i + 1; j < maxLen; j++ {
+					try(i, j)
+				}
+			}
+		}
+		if !better {
+			break
+		}
+		f.Copy(t, best)
+		improved = true
+	}
+	return improved
+}
+
+// About gives a description of the search.
+func (s *TwoFlipSearch) About() string {
+	return fmt.Sprintf("best-improvement two bit flip with at most %d passes and sample %d",
+		s.MaxPasses, s.Sample)
+}
+
+/*
+VNSearch is a randomized variable neighbourhood search. Each of its Iterations
+shakes the try by flipping k items chosen at random, starting with k = 1, and
+then does a single pass of BitFlipSearch. If the result is better it replaces
+the try and k goes back to 1, otherwise k goes up by one, going back to 1 after
+KMax.
+*/
+type VNSearch struct {
+	KMax       int
+	Iterations int
+}
+
+// Search improves t by variable neighbourhood search.
+func (s *VNSearch) Search(f Fun, t Try, maxLen int, rnd *rand.Rand) bool {
+	if maxLen < 1 {
+		return false
+	}
+	kmax := s.KMax
+	if kmax > maxLen {
+		kmax = maxLen
+	}
+	if kmax < 1 {
+		kmax = 1
+	}
+	c := f.NewTry()
+	hint := new(big.Int)
+	descent := &BitFlipSearch{MaxPasses: 1}
+	improved := false
+	k := 1
+	for it := 0; it < s.Iterations; it++ {
+		flip := rnd.Perm(maxLen)[:k]
+		move(f, c, t, hint, flip...)
+		descent.Search(f, c, maxLen, rnd)
+		if f.Cmp(c, t, futil.CostMode) < 0.0 {
+			f.Copy(t, c)
+			improved = true
+			k = 1
+		} else if k++; k > kmax {
+			k = 1
+		}
+	}
+	return improved
+}
+
+// About gives a description of the search.
+func (s *VNSearch) About() string {
+	return fmt.Sprintf("variable neighbourhood with up to %d flips for %d iterations", s.KMax, s.Iterations)
+}
+
+// localSearch is the state of the local search of a swarm.
+type localSearch struct {
+	searcher LocalSearcher
+	// number of updates between searches
+	period int
+	// number of best Personal-bests searched
+	topK int
+	// the cost-function used by the searches, which counts their evaluations
+	f *CountingFun
+	// scratch try
+	t Try
+	// updates since the local search was turned on
+	iter int
+	// number of Personal-bests improved
+	nimproved int
+}
+
+/*
+SetLocalSearch turns on a local search of the topK best Personal-bests, or just
+the global best when topK <= 1, every period updates; a nil searcher turns this
+off. The search is made at the end of PUpdate() after UpdateGlobal() and an
+improved try replaces both the current and Personal-best tries of its particle.
+The cost-function evaluations made by the searches are counted separately from
+those of the updates and are given by LocalSearchEvaluations().
+*/
+func (pso *Pso) SetLocalSearch(ls LocalSearcher, period, topK int) {
+	if ls == nil {
+		pso.local = nil
+		return
+	}
+	if period < 1 {
+		period = 1
+	}
+	if topK < 1 {
+		topK = 1
+	}
+	pso.local = &localSearch{searcher: ls, period: period, topK: topK,
+		f: NewCountingFun(pso.fun, nil), t: pso.fun.NewTry()}
+}
+
+// LocalSearchEvaluations returns the number of cost-function evaluations made
+// by the local search.
+func (pso *Pso) LocalSearchEvaluations() int64 {
+	if pso.local == nil {
+		return 0
+	}
+	return pso.local.f.Evaluations()
+}
+
+// LocalSearchImprovements returns the number of Personal-bests improved by the
+// local search.
+func (pso *Pso) LocalSearchImprovements() int {
+	if pso.local == nil {
+		return 0
+	}
+	return pso.local.nimproved
+}
+
+// localSearch searches the best Personal-bests every period updates. It is
+// called by PUpdate() after UpdateGlobal().
+func (pso *Pso) localSearch() {
+	l := pso.local
+	l.iter++
+	if l.iter%l.period != 0 {
+		return
+	}
+	order := bestFirst(pso)
+	if len(order) > l.topK {
+		order = order[:l.topK]
+	}
+	found := false
+	for _, i := range order {
+		p := &pso.Pt[i]
+		pso.fun.Copy(l.t, p.bestTry)
+		if l.searcher.Search(l.f, l.t, pso.maxLen, pso.rnd) &&
+			pso.fun.Cmp(l.t, p.bestTry, futil.CostMode) < 0.0 {
+			pso.fun.Copy(p.bestTry, l.t)
+			pso.fun.Copy(p.current, l.t)
+			l.nimproved++
+			found = true
+		}
+	}
+	if found {
+		pso.UpdateGlobal()
+	}
+}
@@ -0,0 +1,86 @@
+package setpso_test
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/futil"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+func TestLocalSearch(t *testing.T) {
+	f := subsetsum.New(100, 20, 3142)
+	rnd := rand.New(rand.NewSource(99))
+	start := f.NewTry()
+	f.SetTry(start, randomBits(100, 30, 1))
+	searchers := []setpso.LocalSearcher{
+		&setpso.BitFlipSearch{},
+		&setpso.TwoFlipSearch{MaxPasses: 2},
+		&setpso.TwoFlipSearch{MaxPasses: 3, Sample: 200},
+		&setpso.VNSearch{KMax: 3, Iterations: 20},
+	}
+	for _, s := range searchers {
+		x := f.NewTry()
+		f.Copy(x, start)
+		if !s.Search(f, x, 100, rnd) {
+			t.Errorf("%s found no improvement", s.About())
+		}
+		if f.Cmp(x, start, futil.CostMode) >= 0.0 {
+			t.Errorf("%s gave cost %s from %s", s.About(), x.Cost(), start.Cost())
+		}
+	}
+	// the bit flip search ends at a local optimum
+	x := f.NewTry()
+	f.Copy(x, start)
+	(&setpso.BitFlipSearch{}).Search(f, x, 100, rnd)
+	y := f.NewTry()
+	hint := new(big.Int)
+	for i := 0; i < 100; i++ {
+		hint.SetBit(x.Parameter(), i, x.Parameter().Bit(i)^1)
+		f.Copy(y, x)
+		if f.ToConstraint(y, hint) && f.Cmp(y, x, futil.CostMode) < 0.0 {
+			t.Errorf("flipping item %d improves the bit flip search result", i)
+		}
+	}
+
+	// local search evaluations are counted separately
+	newRun := func(sd int64) (*setpso.GPso, *setpso.EvalCounter) {
+		c := new(setpso.EvalCounter)
+		pso := setpso.NewGPso(setpso.NewPso(20, setpso.NewCountingFun(subsetsum.New(100, 20, 3142), c), sd))
+		pso.SetLocalSearch(&setpso.VNSearch{KMax: 2, Iterations: 5}, 5, 3)
+		return pso, c
+	}
+	pso, c := newRun(578)
+	for i := 0; i < 50; i++ {
+		pso.Update()
+	}
+	ls := pso.LocalSearchEvaluations()
+	if ls == 0 || ls >= c.Evaluations() {
+		t.Errorf("local search made %d of %d evaluations", ls, c.Evaluations())
+	}
+	if pso.LocalSearchImprovements() == 0 {
+		t.Errorf("local search made no improvements")
+	}
+
+	// checkpoints keep the local search state
+	var buf bytes.Buffer
+	if err := pso.Checkpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	q, _ := newRun(99)
+	if err := q.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 23; i++ {
+		pso.Update()
+		q.Update()
+	}
+	samePersonalBests(t, pso, q)
+	if pso.LocalSearchEvaluations() != q.LocalSearchEvaluations() {
+		t.Errorf("restored local search made %d evaluations not %d",
+			q.LocalSearchEvaluations(), pso.LocalSearchEvaluations())
+	}
+}
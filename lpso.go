@@ -0,0 +1,216 @@
+package setpso
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+/*
+Topology is the interface to the neighbourhood graph used by LPso. The graph is
+stored as a list of neighbours for each particle, which does not include the
+particle itself.
+*/
+type Topology interface {
+	// Wire fills in the neighbour list nb[i] of each of the len(nb)
+	// particles using rnd if the graph is random.
+	Wire(nb [][]int, rnd *rand.Rand)
+	// Rewire returns true when the graph should be wired again at the start
+	// of iteration iter.
+	Rewire(iter int) bool
+	// About gives a description of the graph.
+	About() string
+}
+
+// addNeighbour appends j to the neighbours of i unless it is i or already
+// there.
+func addNeighbour(nb [][]int, i, j int) {
+	if j == i {
+		return
+	}
+	for _, k := range nb[i] {
+		if k == j {
+			return
+		}
+	}
+	nb[i] = append(nb[i], j)
+}
+
+// RingTopology links each particle to the K particles either side of it in
+// a ring.
+type RingTopology struct {
+	K int
+}
+
+// Wire links the particles into a ring.
+func (t *RingTopology) Wire(nb [][]int, rnd *rand.Rand) {
+	n := len(nb)
+	for i := range nb {
+		nb[i] = nb[i][:0]
+		for k := 1; k <= t.K; k++ {
+			addNeighbour(nb, i, (i+k)%n)
+			addNeighbour(nb, i, (i-k%n+n)%n)
+		}
+	}
+}
+
+// Rewire returns false since the ring is fixed.
+func (t *RingTopology) Rewire(iter int) bool { return false }
+
+// About gives a description of the ring.
+func (t *RingTopology) About() string {
+	return fmt.Sprintf("ring with %d neighbours each side", t.K)
+}
+
+/*
+VonNeumannTopology links the particles as a wrapped around grid with each
+particle linked to its neighbours above, below, left and right. The grid has
+about Sqrt(n) columns for n particles and the particles are laid out row by row
+with the end of each row wrapping onto the next row so that it works for any
+number of particles.
+*/
+type VonNeumannTopology struct{}
+
+// Wire links the particles into a grid.
+func (t *VonNeumannTopology) Wire(nb [][]int, rnd *rand.Rand) {
+	n := len(nb)
+	cols := int(math.Ceil(math.Sqrt(float64(n))))
+	for i := range nb {
+		nb[i] = nb[i][:0]
+		addNeighbour(nb, i, (i+1)%n)
+		addNeighbour(nb, i, (i-1+n)%n)
+		addNeighbour(nb, i, (i+cols)%n)
+		addNeighbour(nb, i, (i-cols%n+n)%n)
+	}
+}
+
+// Rewire returns false since the grid is fixed.
+func (t *VonNeumannTopology) Rewire(iter int) bool { return false }
+
+// About gives a description of the grid.
+func (t *VonNeumannTopology) About() string {
+	return "Von Neumann grid"
+}
+
+/*
+RandomTopology links each particle to K randomly chosen other particles and
+chooses again every Period iterations.
+*/
+type RandomTopology struct {
+	K      int
+	Period int
+}
+
+// Wire randomly chooses the neighbours of each particle.
+func (t *RandomTopology) Wire(nb [][]int, rnd *rand.Rand) {
+	n := len(nb)
+	for i := range nb {
+		nb[i] = nb[i][:0]
+		for k := 0; k < t.K; k++ {
+			addNeighbour(nb, i, rnd.Intn(n))
+		}
+	}
+}
+
+// Rewire returns true every Period iterations.
+func (t *RandomTopology) Rewire(iter int) bool {
+	return t.Period > 0 && iter > 0 && iter%t.Period == 0
+}
+
+// About gives a description of the random graph.
+func (t *RandomTopology) About() string {
+	return fmt.Sprintf("%d random neighbours rewired every %d iterations", t.K, t.Period)
+}
+
+/*
+LPso is a local-best PSO where each particle has its own group with one target
+that is the best Personal-best among the particle and its neighbours in a
+neighbourhood graph given by a Topology. All particles share Heuristics.
+*/
+type LPso struct {
+	*Pso
+	top Topology
+	// neighbours of each particle
+	nb [][]int
+	// iteration count used for rewiring
+	iter int
+}
+
+// NewLPso creates a LPso using the neighbourhood graph top.
+func NewLPso(p *Pso, top Topology) *LPso {
+	pso := &LPso{Pso: p, top: top}
+	pso.nb = make([][]int, pso.Nparticles())
+	for i := range pso.nb {
+		gp := pso.CreateGroup(strconv.Itoa(i), 1)
+		pso.MoveTo(gp, i)
+		pso.SetGroupTarget(gp, i)
+	}
+	pso.top.Wire(pso.nb, pso.rnd)
+	return pso
+}
+
+// SetHeuristics sets the heuristics for the particle swarm.
+func (p *LPso) SetHeuristics(hu *PsoHeuristics) { p.hu = hu }
+
+// Topology returns the neighbourhood graph in use.
+func (p *LPso) Topology() Topology { return p.top }
+
+// Neighbours returns the neighbours of the ith particle.
+func (p *LPso) Neighbours(i int) []int { return p.nb[i] }
+
+/*
+Update rewires the neighbourhood graph if the Topology asks for it and then
+sets the target of each particle to the particle with the best Personal-best
+among itself and its neighbours. After this it does the usual PUpdate().
+*/
+func (p *LPso) Update() {
+	p.ApplySchedules()
+	if p.top.Rewire(p.iter) {
+		p.top.Wire(p.nb, p.rnd)
+	}
+	for i := range p.nb {
+		best := i
+		for _, j := range p.nb[i] {
+			if p.fun.Cmp(p.Pt[best].bestTry, p.Pt[j].bestTry, futil.CostMode) > 0.0 {
+				best = j
+			}
+		}
+		p.SetGroupTarget(p.Group(i), best)
+	}
+	p.PUpdate()
+	p.iter++
+}
+
+// Checkpoint writes the state of the LPso including its neighbourhood graph
+// to w.
+func (p *LPso) Checkpoint(w io.Writer) error {
+	st := p.state()
+	st.Iter = p.iter
+	st.Neighbours = p.nb
+	return gob.NewEncoder(w).Encode(st)
+}
+
+// Restore reads a state written by Checkpoint() from r and replaces the state
+// of the LPso by it.
+func (p *LPso) Restore(r io.Reader) error {
+	var st psoState
+	if err := gob.NewDecoder(r).Decode(&st); err != nil {
+		return err
+	}
+	if len(st.Neighbours) != len(p.nb) {
+		return fmt.Errorf("checkpoint was not made by a LPso with %d particles", len(p.nb))
+	}
+	if err := p.setState(&st); err != nil {
+		return err
+	}
+	p.iter = st.Iter
+	for i := range p.nb {
+		p.nb[i] = append(p.nb[i][:0], st.Neighbours[i]...)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package setpso_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+func TestTopology(t *testing.T) {
+	n := 10
+	nb := make([][]int, n)
+	cases := []struct {
+		top   setpso.Topology
+		count int
+	}{
+		{&setpso.RingTopology{K: 1}, 2},
+		{&setpso.RingTopology{K: 2}, 4},
+		{&setpso.VonNeumannTopology{}, 4},
+		{&setpso.RandomTopology{K: 1, Period: 10}, 1},
+	}
+	for _, c := range cases {
+		c.top.Wire(nb, rand.New(rand.NewSource(1)))
+		for i := range nb {
+			if len(nb[i]) > c.count {
+				t.Errorf("%s: particle %d has %d neighbours", c.top.About(), i, len(nb[i]))
+			}
+			for _, j := range nb[i] {
+				if j == i || j < 0 || j >= n {
+					t.Errorf("%s: particle %d has neighbour %d", c.top.About(), i, j)
+				}
+			}
+		}
+	}
+	p := setpso.NewLPso(setpso.NewPso(n, subsetsum.New(100, 20, 3142), 578),
+		&setpso.RingTopology{K: 1})
+	for i := 0; i < 50; i++ {
+		p.Update()
+	}
+	for i := 0; i < n; i++ {
+		if len(p.Neighbours(i)) != 2 {
+			t.Errorf("ring particle %d has neighbours %v", i, p.Neighbours(i))
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package setpso
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+/*
+NPso is a niching PSO that splits the particles into species so that several
+optima can be found at the same time. Every Period updates the particles are
+clustered by the Hamming distance between their Personal-best Parameters: in
+order of increasing Personal-best cost each particle joins the first species
+whose seed, the best particle of the species, is less than Radius bits away,
+otherwise it becomes the seed of a new species. When MaxSize > 0 a species
+that has MaxSize members takes no more. Each species is a Group that targets
+its own best particle. All particles share Heuristics.
+*/
+type NPso struct {
+	*Pso
+	// Hamming distance within which a particle joins a species
+	Radius int
+	// number of updates between clustering
+	Period int
+	// maximum number of members of a species; 0 for no limit
+	MaxSize int
+	// species groups; some may have no members
+	species []*Group
+	// update count
+	iter int
+}
+
+// speciesPrefix starts the name of each species group.
+const speciesPrefix = "species"
+
+// NewNPso creates a NPso with the given species radius and clustering period.
+func NewNPso(p *Pso, radius, period int) *NPso {
+	pso := &NPso{Pso: p, Radius: radius, Period: period}
+	pso.Speciate()
+	return pso
+}
+
+// SetHeuristics sets the heuristics for the particle swarm.
+func (p *NPso) SetHeuristics(hu *PsoHeuristics) { p.hu = hu }
+
+/*
+Speciate clusters the particles into species as described for NPso, moving
+them into the species groups, and updates the group bests. It is called by
+Update() every Period updates.
+*/
+func (p *NPso) Speciate() {
+	order := bestFirst(p.Pso)
+	var seeds []int
+	size := make([]int, 0, len(p.species))
+	diff := new(big.Int)
+	for _, i := range order {
+		k := 0
+		for ; k < len(seeds); k++ {
+			if p.MaxSize > 0 && size[k] >= p.MaxSize {
+				continue
+			}
+			diff.Xor(p.Pt[i].bestTry.Parameter(), p.Pt[seeds[k]].bestTry.Parameter())
+			if CardinalSize(diff) < p.Radius {
+				break
+			}
+		}
+		if k == len(seeds) {
+			seeds = append(seeds, i)
+			size = append(size, 0)
+			if k == len(p.species) {
+				p.species = append(p.species, p.CreateGroup(speciesPrefix+strconv.Itoa(k), 1))
+			}
+		}
+		size[k]++
+		if g := p.species[k]; p.Group(i) != g {
+			p.MoveTo(g, i)
+		}
+	}
+	p.UpdateGlobal()
+}
+
+/*
+Update clusters the particles every Period updates and sets the target of each
+species to its best particle. After this it does the usual PUpdate().
+*/
+func (p *NPso) Update() {
+	p.ApplySchedules()
+	if p.iter > 0 && p.Period > 0 && p.iter%p.Period == 0 {
+		p.Speciate()
+	}
+	for _, g := range p.species {
+		if len(g.members) > 0 {
+			p.SetGroupTarget(g, p.GroupBest(g))
+		}
+	}
+	p.PUpdate()
+	p.iter++
+}
+
+// Niche describes a species found by NPso.
+type Niche struct {
+	// the particle with the best Personal-best in the species
+	Best int
+	// the particles in the species
+	Members []int
+}
+
+// Niches returns the species that have members in order of increasing cost of
+// their best Personal-best.
+func (p *NPso) Niches() []Niche {
+	var ns []Niche
+	for _, g := range p.species {
+		if len(g.members) > 0 {
+			ns = append(ns, Niche{g.bestMember, append([]int(nil), g.members...)})
+		}
+	}
+	sort.SliceStable(ns, func(a, b int) bool {
+		return p.fun.Cmp(p.Pt[ns[a].Best].bestTry, p.Pt[ns[b].Best].bestTry, futil.CostMode) < 0.0
+	})
+	return ns
+}
+
+// Checkpoint writes the state of the NPso to w.
+func (p *NPso) Checkpoint(w io.Writer) error {
+	st := p.state()
+	st.Iter = p.iter
+	return gob.NewEncoder(w).Encode(st)
+}
+
+// Restore reads a state written by Checkpoint() from r and replaces the state
+// of the NPso by it.
+func (p *NPso) Restore(r io.Reader) error {
+	var st psoState
+	if err := gob.NewDecoder(r).Decode(&st); err != nil {
+		return err
+	}
+	if err := p.setState(&st); err != nil {
+		return err
+	}
+	p.iter = st.Iter
+	// the groups are rebuilt so find the species again
+	p.species = p.species[:0]
+	for k := 0; ; k++ {
+		g := p.Gr(speciesPrefix + strconv.Itoa(k))
+		if g == nil {
+			break
+		}
+		p.species = append(p.species, g)
+	}
+	for name := range p.gr {
+		if strings.HasPrefix(name, speciesPrefix) {
+			if k, err := strconv.Atoi(name[len(speciesPrefix):]); err != nil || k >= len(p.species) {
+				return fmt.Errorf("checkpoint has unexpected species %s", name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package setpso_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/multimode"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+func TestNPso(t *testing.T) {
+	newRun := func(sd int64) *setpso.NPso {
+		f := multimode.NewFun(4, 16, 0.1, 0.0, 100, 2, 3142)
+		return setpso.NewNPso(setpso.NewPso(30, f, sd), 6, 10)
+	}
+	pso := newRun(578)
+	for i := 0; i < 300; i++ {
+		pso.Update()
+	}
+	pso.Speciate()
+	ns := pso.Niches()
+	if len(ns) < 2 {
+		t.Fatalf("found %d niches", len(ns))
+	}
+	seen := make(map[int]bool)
+	diff := new(big.Int)
+	for k, n := range ns {
+		seed := pso.LocalBestTry(n.Best).Parameter()
+		for _, i := range n.Members {
+			if seen[i] {
+				t.Errorf("particle %d is in more than one niche", i)
+			}
+			seen[i] = true
+			if d := setpso.CardinalSize(diff.Xor(pso.LocalBestTry(i).Parameter(), seed)); d >= pso.Radius {
+				t.Errorf("particle %d is %d bits from the best of niche %d", i, d, k)
+			}
+		}
+	}
+	if len(seen) != pso.Nparticles() {
+		t.Errorf("niches hold %d of %d particles", len(seen), pso.Nparticles())
+	}
+
+	// checkpoints keep the species; the multimode function has its own
+	// random numbers so a deterministic function is used
+	newSubset := func(sd int64) *setpso.NPso {
+		return setpso.NewNPso(setpso.NewPso(20, subsetsum.New(100, 20, 3142), sd), 30, 10)
+	}
+	p := newSubset(578)
+	for i := 0; i < 55; i++ {
+		p.Update()
+	}
+	var buf bytes.Buffer
+	if err := p.Checkpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	q := newSubset(99)
+	if err := q.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		p.Update()
+		q.Update()
+	}
+	samePersonalBests(t, p, q)
+}
@@ -0,0 +1,338 @@
+package setpso
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+/*
+ParetoArchive keeps up to size of the non-dominated tries offered to it, which
+approximate the Pareto front of a multi-objective cost-function. The tries must
+implement futil.VecCoster, as futil.VecTry does. When the archive is full the
+entry in the most crowded part of the front, the one with the least crowding
+distance, is dropped so that the front stays spread out.
+*/
+type ParetoArchive struct {
+	size int
+	// entries in order of increasing costs, first objective first
+	tries []Try
+}
+
+// NewParetoArchive creates an empty archive of at most size tries.
+func NewParetoArchive(size int) *ParetoArchive {
+	if size < 1 {
+		size = 1
+	}
+	return &ParetoArchive{size: size}
+}
+
+// costs returns the cost vector of t.
+func costs(t Try) []float64 { return t.(futil.VecCoster).CostVector() }
+
+// lessCosts orders cost vectors by their first cost, then their second and so
+// on.
+func lessCosts(x, y []float64) bool {
+	for i := range x {
+		if x[i] != y[i] {
+			return x[i] < y[i]
+		}
+	}
+	return false
+}
+
+// equalCosts returns true if the cost vectors are equal.
+func equalCosts(x, y []float64) bool {
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Add offers a copy of t to the archive. t is not added if an entry dominates it
+or has the same costs, otherwise the entries it dominates are dropped and it is
+added, after which the most crowded entry is dropped if the archive is over
+size. It returns true if t is in the archive afterwards.
+*/
+func (a *ParetoArchive) Add(f Fun, t Try) bool {
+	c := costs(t)
+	var spare Try
+	kept := a.tries[:0]
+	for _, e := range a.tries {
+		ec := costs(e)
+		if futil.Dominates(ec, c) || equalCosts(ec, c) {
+			// t cannot dominate any entry so none has been dropped
+			return false
+		}
+		if futil.Dominates(c, ec) {
+			spare = e
+			continue
+		}
+		kept = append(kept, e)
+	}
+	a.tries = kept
+	if spare == nil {
+		spare = f.NewTry()
+	}
+	f.Copy(spare, t)
+	k := sort.Search(len(a.tries), func(i int) bool { return lessCosts(c, costs(a.tries[i])) })
+	a.tries = append(a.tries, nil)
+	copy(a.tries[k+1:], a.tries[k:])
+	a.tries[k] = spare
+	if len(a.tries) <= a.size {
+		return true
+	}
+	d := a.Crowding()
+	worst := 0
+	for i := range d {
+		if d[i] < d[worst] {
+			worst = i
+		}
+	}
+	a.tries = append(a.tries[:worst], a.tries[worst+1:]...)
+	return worst != k
+}
+
+// Len returns the number of tries in the archive.
+func (a *ParetoArchive) Len() int { return len(a.tries) }
+
+// Front returns the tries in the archive in order of increasing costs, first
+// objective first.
+func (a *ParetoArchive) Front() []Try { return append([]Try(nil), a.tries...) }
+
+/*
+Crowding returns the crowding distance of each try in Front() order. For each
+objective the tries with the least and greatest cost have an infinite distance
+and the others add the difference in cost of their neighbours in that
+objective divided by the range of the objective's costs.
+*/
+func (a *ParetoArchive) Crowding() []float64 {
+	n := len(a.tries)
+	d := make([]float64, n)
+	if n == 0 {
+		return d
+	}
+	order := make([]int, n)
+	for m := range costs(a.tries[0]) {
+		for i := range order {
+			order[i] = i
+		}
+		cm := func(i int) float64 { return costs(a.tries[order[i]])[m] }
+		sort.SliceStable(order, func(i, j int) bool {
+			return costs(a.tries[order[i]])[m] < costs(a.tries[order[j]])[m]
+		})
+		d[order[0]] = math.Inf(1)
+		d[order[n-1]] = math.Inf(1)
+		span := cm(n-1) - cm(0)
+		if span <= 0 {
+			continue
+		}
+		for i := 1; i < n-1; i++ {
+			d[order[i]] += (cm(i+1) - cm(i-1)) / span
+		}
+	}
+	return d
+}
+
+/*
+LeaderSelector is the interface to a strategy used by MOPso to choose the
+leader of each group from a ParetoArchive, which the members of the group learn
+from.
+*/
+type LeaderSelector interface {
+	// Leaders sets leaders[k] to the index in a.Front() of the leader of the
+	// kth group; a has at least one try.
+	Leaders(a *ParetoArchive, leaders []int, rnd *rand.Rand)
+	// About gives a description of the strategy.
+	About() string
+}
+
+/*
+CrowdingLeaders chooses each leader by a binary tournament on crowding
+distance so that leaders in the less crowded parts of the front are preferred,
+which spreads the swarm along the front.
+*/
+type CrowdingLeaders struct{}
+
+// Leaders chooses the leaders by crowding distance.
+func (s CrowdingLeaders) Leaders(a *ParetoArchive, leaders []int, rnd *rand.Rand) {
+	d := a.Crowding()
+	for k := range leaders {
+		i1 := rnd.Intn(len(d))
+		i2 := rnd.Intn(len(d))
+		if d[i2] > d[i1] {
+			i1 = i2
+		}
+		leaders[k] = i1
+	}
+}
+
+// About gives a description of the strategy.
+func (s CrowdingLeaders) About() string { return "crowding distance tournament" }
+
+// RandomLeaders chooses each leader uniformly at random.
+type RandomLeaders struct{}
+
+// Leaders chooses the leaders at random.
+func (s RandomLeaders) Leaders(a *ParetoArchive, leaders []int, rnd *rand.Rand) {
+	for k := range leaders {
+		leaders[k] = rnd.Intn(a.Len())
+	}
+}
+
+// About gives a description of the strategy.
+func (s RandomLeaders) About() string { return "uniformly random" }
+
+/*
+Fronter is the interface for multi-objective SPSOs that keep an approximation
+of the Pareto front.
+*/
+type Fronter interface {
+	// Front returns the non-dominated tries found in order of increasing
+	// costs, first objective first.
+	Front() []Try
+}
+
+/*
+MOPso is a multi-objective PSO for cost-functions with a vector of costs, such
+as those using futil.VecFunStub, whose Cmp() uses Pareto dominance so a
+Personal-best is only replaced by a try that dominates it. The particles are
+split into groups, named "mo0", "mo1" and so on, and each update a
+LeaderSelector chooses a leader for each group from a ParetoArchive of the
+non-dominated tries found so far. Each member of a group learns from its
+leader using SetExemplar() in place of a Target. The current and Personal-best
+tries are offered to the archive after each update. All particles share
+Heuristics.
+*/
+type MOPso struct {
+	*Pso
+	archive  *ParetoArchive
+	selector LeaderSelector
+	groups   []*Group
+	// archive index of the leader of each group
+	leaders []int
+	// Parameters of the leader of each group
+	exemplars []*big.Int
+}
+
+// moPrefix starts the name of each MOPso group.
+const moPrefix = "mo"
+
+/*
+NewMOPso creates a MOPso with ngroups groups, at least 1, and an archive of at
+most archiveSize tries using the leader selection strategy ls. It returns an
+error if the tries of the cost-function do not have a vector of costs.
+*/
+func NewMOPso(p *Pso, ngroups, archiveSize int, ls LeaderSelector) (*MOPso, error) {
+	if _, ok := p.fun.NewTry().(futil.VecCoster); !ok {
+		return nil, fmt.Errorf("cost-function tries of type %T do not have a vector of costs", p.fun.NewTry())
+	}
+	if ngroups < 1 {
+		ngroups = 1
+	}
+	if ngroups > p.Nparticles() {
+		ngroups = p.Nparticles()
+	}
+	pso := &MOPso{Pso: p, archive: NewParetoArchive(archiveSize), selector: ls}
+	pso.leaders = make([]int, ngroups)
+	for k := 0; k < ngroups; k++ {
+		pso.groups = append(pso.groups, pso.CreateGroup(moPrefix+strconv.Itoa(k), 1))
+		pso.exemplars = append(pso.exemplars, new(big.Int))
+	}
+	for i := range pso.Pt {
+		pso.MoveTo(pso.groups[i%ngroups], i)
+	}
+	pso.link()
+	pso.UpdateGlobal()
+	pso.offer()
+	return pso, nil
+}
+
+// link sets each particle to learn from the leader of its group.
+func (p *MOPso) link() {
+	for k, g := range p.groups {
+		for _, i := range g.members {
+			p.SetExemplar(i, p.exemplars[k])
+		}
+	}
+}
+
+// offer offers the current and Personal-best tries to the archive.
+func (p *MOPso) offer() {
+	for i := range p.Pt {
+		p.archive.Add(p.fun, p.Pt[i].current)
+		p.archive.Add(p.fun, p.Pt[i].bestTry)
+	}
+}
+
+//SetHeuristics sets the heuristics for the particle swarm.
+func (p *MOPso) SetHeuristics(hu *PsoHeuristics) { p.hu = hu }
+
+// Update chooses the leader of each group from the archive, does the usual
+// PUpdate() and then offers the tries to the archive.
+func (p *MOPso) Update() {
+	p.ApplySchedules()
+	p.selector.Leaders(p.archive, p.leaders, p.rnd)
+	for k := range p.groups {
+		p.exemplars[k].Set(p.archive.tries[p.leaders[k]].Parameter())
+	}
+	p.PUpdate()
+	p.offer()
+}
+
+// Front returns the tries in the archive in order of increasing costs, first
+// objective first.
+func (p *MOPso) Front() []Try { return p.archive.Front() }
+
+// Archive returns the Pareto archive.
+func (p *MOPso) Archive() *ParetoArchive { return p.archive }
+
+// Checkpoint writes the state of the MOPso including its archive to w.
+func (p *MOPso) Checkpoint(w io.Writer) error {
+	st := p.state()
+	for _, t := range p.archive.tries {
+		st.Front = append(st.Front, new(big.Int).Set(t.Parameter()))
+	}
+	return gob.NewEncoder(w).Encode(st)
+}
+
+// Restore reads a state written by Checkpoint() from r and replaces the state
+// of the MOPso by it.
+func (p *MOPso) Restore(r io.Reader) error {
+	var st psoState
+	if err := gob.NewDecoder(r).Decode(&st); err != nil {
+		return err
+	}
+	if len(st.Front) == 0 {
+		return fmt.Errorf("checkpoint was not made by a MOPso")
+	}
+	if err := p.setState(&st); err != nil {
+		return err
+	}
+	// the groups are rebuilt so find them again
+	for k := range p.groups {
+		g := p.Gr(moPrefix + strconv.Itoa(k))
+		if g == nil {
+			return fmt.Errorf("checkpoint has no group %s%d", moPrefix, k)
+		}
+		p.groups[k] = g
+	}
+	p.link()
+	p.archive.tries = p.archive.tries[:0]
+	t := p.fun.NewTry()
+	for _, x := range st.Front {
+		p.fun.SetTry(t, x)
+		p.archive.Add(p.fun, t)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package setpso_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/futil"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+func TestMOPso(t *testing.T) {
+	if _, err := setpso.NewMOPso(newPso(10), 2, 10,
+		setpso.CrowdingLeaders{}); err == nil {
+		t.Errorf("single cost function accepted")
+	}
+	newRun := func(sd int64) *setpso.MOPso {
+		pso, err := setpso.NewMOPso(setpso.NewPso(20, subsetsum.NewMO(100, 20, 3142), sd), 4, 20,
+			setpso.CrowdingLeaders{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pso
+	}
+	pso := newRun(578)
+	for i := 0; i < 200; i++ {
+		pso.Update()
+	}
+	front := pso.Front()
+	if len(front) < 3 || len(front) > 20 {
+		t.Fatalf("front has %d tries", len(front))
+	}
+	for k := range front {
+		ck := front[k].(*futil.VecTry).CostVector()
+		if k > 0 && front[k-1].(*futil.VecTry).CostVector()[0] >= ck[0] {
+			t.Errorf("front is not in order of the first cost at %d", k)
+		}
+		for j := range front {
+			if futil.Dominates(front[j].(*futil.VecTry).CostVector(), ck) {
+				t.Errorf("front try %d dominates %d", j, k)
+			}
+		}
+	}
+
+	// the archive keeps the spread out tries when full
+	a := setpso.NewParetoArchive(3)
+	f := subsetsum.NewMO(100, 20, 3142)
+	for _, x := range front {
+		a.Add(f, x)
+	}
+	if a.Len() != 3 {
+		t.Fatalf("archive has %d tries", a.Len())
+	}
+	af := a.Front()
+	if af[0].Cost() != front[0].Cost() || af[2].Cost() != front[len(front)-1].Cost() {
+		t.Errorf("archive lost the ends of the front")
+	}
+	if a.Add(f, front[1]) && a.Len() > 3 {
+		t.Errorf("archive has grown to %d tries", a.Len())
+	}
+
+	// checkpoints keep the archive
+	var buf bytes.Buffer
+	if err := pso.Checkpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	q := newRun(99)
+	if err := q.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		pso.Update()
+		q.Update()
+	}
+	samePersonalBests(t, pso, q)
+	pf, qf := pso.Front(), q.Front()
+	if len(pf) != len(qf) {
+		t.Fatalf("restored front has %d tries not %d", len(qf), len(pf))
+	}
+	for k := range pf {
+		if pf[k].Parameter().Cmp(qf[k].Parameter()) != 0 {
+			t.Errorf("restored front differs at %d", k)
+		}
+	}
+}
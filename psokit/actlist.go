@@ -1,12 +1,17 @@
 package psokit
 
 import (
+	"bytes"
+	"encoding/gob"
 	"flag"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"sort"
 
+	"github.com/mathrgo/setpso"
+
 	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/plotter"
 	"gonum.org/v1/plot/vg"
@@ -26,10 +31,26 @@ func (man *ManPso) SelectActs(ac ...string) error {
 			a = new(Printheading)
 		case "plot-personal-best":
 			a = new(PlotPersonalBest)
+		case "plot-personal-best-evals":
+			a = &PlotPersonalBest{XEvals: true}
 		case "use-cmd-options":
 			a = new(CmdOptions)
 		case "run-progress":
 			a = new(RunProgress)
+		case "checkpoint":
+			a = new(Checkpoint)
+		case "adapt-log":
+			a = new(AdaptLog)
+		case "print-elite":
+			a = new(PrintElite)
+		case "print-niches":
+			a = new(PrintNiches)
+		case "print-front":
+			a = new(PrintFront)
+		case "plot-front":
+			a = new(PlotFront)
+		case "plot-entropy":
+			a = new(PlotEntropy)
 		default:
 			a = man.addedAct[name]
 			//fmt.Printf("found: %v\n", a)
@@ -68,11 +89,19 @@ actions
 func (man *ManPso) loadActDescription() {
 	man.actd = map[string]string{
 
-		"print-result":       "Print final result at end of run; using Printresult ",
-		"print-headings":     "Prints setup and run headings; using Printheading",
-		"plot-personal-best": "Plots the personal best during a run; using PlotPersonalBest",
-		"use-cmd-options":    "Use command options to change configuration; using CmdOptions",
-		"run-progress":       "Prints run progress; using RunProgress",
+		"print-result":             "Print final result at end of run; using Printresult ",
+		"print-headings":           "Prints setup and run headings; using Printheading",
+		"plot-personal-best":       "Plots the personal best during a run; using PlotPersonalBest",
+		"plot-personal-best-evals": "Plots the personal best against cost-function evaluations during a run; using PlotPersonalBest",
+		"use-cmd-options":          "Use command options to change configuration; using CmdOptions",
+		"run-progress":             "Prints run progress; using RunProgress",
+		"checkpoint":               "Saves the SPSO state during a run and restores it after a crash; using Checkpoint",
+		"print-niches":             "Prints the niches found by a niching SPSO at end of run; using PrintNiches",
+		"print-elite":              "Prints the elite archive of distinct good solutions at end of run; using PrintElite",
+		"print-front":              "Prints the Pareto front found by a multi-objective SPSO at end of run; using PrintFront",
+		"plot-front":               "Plots the first two costs of the Pareto front found by a multi-objective SPSO at end of run; using PlotFront",
+		"plot-entropy":             "Plots the entropy of the model of an estimation of distribution optimizer during a run; using PlotEntropy",
+		"adapt-log":                "Logs adapted heuristics of an adaptive SPSO to a file and prints the best at end of run; using AdaptLog",
 	}
 }
 
@@ -109,6 +138,20 @@ func (a *Printresult) Result(man *ManPso) {
 	fmt.Printf(" Best Particle: %d\n", p.BestParticle())
 	fmt.Printf(" Cost: %s\n", try.Cost())
 	fmt.Printf("%s\n", try.Decode())
+	if b, ok := p.(interface{ Base() *setpso.Pso }); ok && man.Restart() != nil {
+		fmt.Printf(" Restarts: %d\n", b.Base().Restarts())
+	}
+	if b, ok := p.(interface{ Base() *setpso.Pso }); ok && man.LocalSearch() != "none" {
+		fmt.Printf(" Local search evaluations: %d improvements: %d\n",
+			b.Base().LocalSearchEvaluations(), b.Base().LocalSearchImprovements())
+	}
+	if b, ok := p.(interface{ Base() *setpso.Pso }); ok && man.Racer() != "none" {
+		fmt.Printf(" Race evaluations: %d wins: %d\n",
+			b.Base().RaceEvaluations(), b.Base().RaceWins())
+	}
+	if man.CostCache() > 0 {
+		fmt.Printf(" Cost cache hit rate: %.3f\n", man.CacheHitRate())
+	}
 }
 
 /*
@@ -160,6 +203,8 @@ func (a *RunProgress) DataUpdate(man *ManPso) {
 // particle
 type ResultsArray struct {
 	points []plotter.XYs
+	// X-axis label
+	xlabel string
 }
 
 /*
@@ -172,9 +217,25 @@ func NewResultsArray(datalength, dimension int) *ResultsArray {
 	for i := 0; i < dimension; i++ {
 		r.points[i] = make(plotter.XYs, datalength)
 	}
+	r.xlabel = "iteration"
 	return &r
 }
 
+//SetXLabel sets the X-axis label, which is iteration by default.
+func (r *ResultsArray) SetXLabel(label string) { r.xlabel = label }
+
+/*
+Truncate keeps only the first n data entries, which is used when a run ends
+before all the data entries are filled.
+*/
+func (r *ResultsArray) Truncate(n int) {
+	for i := range r.points {
+		if n < len(r.points[i]) {
+			r.points[i] = r.points[i][:n]
+		}
+	}
+}
+
 /*
 ResUpdate puts val into the plotting results array for value index valueID and
 data slot dataID where valID is the number of iterations so far in a run.
@@ -234,7 +295,7 @@ func (r *ResultsArray) NewPlot(yaxisname, title string, runid int) {
 		pl1.Add(pl1Line)
 	}
 	pl1.Title.Text = fmt.Sprintf("%s of particle: Run %d", title, runid)
-	pl1.X.Label.Text = "iteration"
+	pl1.X.Label.Text = r.xlabel
 	pl1.Y.Label.Text = yaxisname
 	FixLinAxis(&(pl1.Y))
 	// use Log scale on iterations
@@ -252,22 +313,32 @@ func (r *ResultsArray) NewPlot(yaxisname, title string, runid int) {
 
 }
 
-//PlotPersonalBest plots the personal best costs of each  Particle during a run.
-// It implements the plot-personal-best Action.
+// PlotPersonalBest plots the personal best costs of each  Particle during a run.
+// It implements the plot-personal-best Action and when XEvals is true the
+// plot-personal-best-evals Action, which uses the number of cost-function
+// evaluations as the X-axis instead of iterations.
 type PlotPersonalBest struct {
 	*ResultsArray
+	XEvals bool
 }
 
 //RunInit setup plotting arrays for the run
 func (pl *PlotPersonalBest) RunInit(man *ManPso) {
-	*pl = PlotPersonalBest{NewResultsArray(man.Datalength(), man.Npart())}
+	pl.ResultsArray = NewResultsArray(man.Datalength(), man.Npart())
+	if pl.XEvals {
+		pl.SetXLabel("evaluations")
+	}
 }
 
 //DataUpdate loads personal best costs into plot
 func (pl *PlotPersonalBest) DataUpdate(man *ManPso) {
 	p := man.P()
+	x := man.Iter()
+	if pl.XEvals {
+		x = int(man.Evaluations())
+	}
 	for j := 0; j < man.Npart(); j++ {
-		pl.ResUpdate(p.Part(j).BestTry().Fbits(), man.Diter(), j, man.Iter())
+		pl.ResUpdate(p.Part(j).BestTry().Fbits(), man.Diter(), j, x)
 	}
 }
 
@@ -278,6 +349,7 @@ Result generates the plots of the personal best and puts it into  the file of th
 
 */
 func (pl *PlotPersonalBest) Result(man *ManPso) {
+	pl.Truncate(man.Diter())
 	pl.NewPlot("Fbits(Cost)", "Personal Best", man.RunID())
 }
 
@@ -290,18 +362,44 @@ type CmdOptions struct{}
 
 //Init reads the command options.
 func (cmd *CmdOptions) Init(man *ManPso) {
-	var optCase, funCase string
-	var debug, listFun, listPso, listAct bool
-	var stopAt, nrun, npart int
+	var optCase, funCase, combiner, schedules, initCase, lsCase, raceCase string
+	var debug, listFun, listPso, listAct, listSched bool
+	var sparse float64
+	var evals int64
+	var cache, restart, elite, edist, lsPeriod, lsTopK, raceBudget int
+	var stopAt, nrun, npart, nworker, replace, initBudget int
 	flag.StringVar(&optCase, "pso", man.PsoCase(), "name of PSO")
 	flag.StringVar(&funCase, "fun", man.FunCase(), "name of function to optimise")
+	flag.StringVar(&combiner, "comb", man.Combiner(), "velocity probability combiner: pseudo-add, max, capped-sum or noisy-or")
+	flag.StringVar(&schedules, "sched", man.Schedules(), "heuristic schedules as a list such as omega=linear-half,lfactor=cosine-4")
 	flag.BoolVar(&debug, "dump", man.DebugDump(), "set to true when debug dumping")
 	flag.IntVar(&stopAt, "dbstop", man.StopAt(), "cycle to stop at when doing a debug dump")
 	flag.IntVar(&nrun, "nrun", man.Nrun(), "number of independent runs when not debug dumping")
 	flag.IntVar(&npart, "npart", man.Npart(), "number of independent runs when not debug dumping")
+	flag.IntVar(&nworker, "nworker", man.Nworker(), "number of workers evaluating particle costs concurrently; refused for noisy cost-functions")
+	flag.StringVar(&initCase, "init", man.InitCase(), "initialization strategy: random, budget, fallback, opposition or maximin")
+	flag.IntVar(&initBudget, "ibudget", man.InitBudget(), "attempt budget for initializing each particle")
+	flag.IntVar(&replace, "replace", man.replaceWindow, "window of updates for replacing unused items; 0 for no replacement; ignored by islands")
+	flag.Int64Var(&evals, "evals", man.EvalBudget(), "cost-function evaluation budget of each run; 0 for no budget")
+	if r := man.Restart(); r != nil {
+		restart = r.MaxAge
+	}
+	flag.IntVar(&restart, "restart", restart, "updates without improvement before restarting half the swarm keeping the best; 0 for no restarts")
+	elite, edist = man.EliteArchive()
+	flag.IntVar(&elite, "elite", elite, "size of the archive of distinct good solutions; 0 for no archive")
+	flag.IntVar(&edist, "edist", edist, "minimum Hamming distance between the elite archive entries")
+	flag.IntVar(&cache, "cache", man.CostCache(), "number of tries in the cost cache of deterministic cost-functions; 0 for no cache")
+	flag.StringVar(&lsCase, "ls", man.LocalSearch(), "local search of the best solutions: none, flip, 2flip or vns")
+	lsPeriod, lsTopK = man.LocalSearchPeriod()
+	flag.IntVar(&lsPeriod, "lsperiod", lsPeriod, "updates between local searches")
+	flag.IntVar(&lsTopK, "lstop", lsTopK, "number of best solutions searched by each local search")
+	flag.StringVar(&raceCase, "racer", man.Racer(), "racing of noisy solutions: none, ocba, hoeffding or ttest")
+	flag.IntVar(&raceBudget, "racebudget", man.RaceBudget(), "extra cost samples drawn by racing each update")
+	flag.Float64Var(&sparse, "sparse", man.SparseVelocity(), "cut off for sparse velocities; 0 for dense velocities")
 	flag.BoolVar(&listFun, "listf", false, "list available cost-function")
 	flag.BoolVar(&listPso, "listp", false, "list available SPSO")
 	flag.BoolVar(&listAct, "lista", false, "list available Actions")
+	flag.BoolVar(&listSched, "lists", false, "list available heuristic schedules")
 
 	flag.Parse()
 	if flag.NFlag() == 0 {
@@ -319,8 +417,46 @@ func (cmd *CmdOptions) Init(man *ManPso) {
 		fmt.Print(man.FunDescription())
 		os.Exit(1)
 	}
+	if err := man.SelectCombiner(combiner); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	man.SetInitBudget(initBudget)
+	if err := man.SelectInit(initCase); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := man.SetSchedules(schedules); err != nil {
+		fmt.Println(err)
+		fmt.Print(man.ScheduleDescription())
+		os.Exit(1)
+	}
+	if err := man.SelectLocalSearch(lsCase); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	man.SetLocalSearchPeriod(lsPeriod, lsTopK)
+	if err := man.SelectRacer(raceCase); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	man.SetRaceBudget(raceBudget)
 	man.SetNrun(nrun)
 	man.SetNpart(npart)
+	man.SetNworker(nworker)
+	man.SetItemReplacement(replace, man.replaceThreshold)
+	man.SetSparseVelocity(sparse)
+	man.SetEvalBudget(evals)
+	man.SetCostCache(cache)
+	man.SetEliteArchive(elite, edist)
+	switch r := man.Restart(); {
+	case restart <= 0:
+		man.SetRestart(nil)
+	case r != nil:
+		r.MaxAge = restart
+	default:
+		man.SetRestart(&setpso.RestartPolicy{MaxAge: restart, Fraction: 0.5, Elite: 1, ArchiveSize: 5})
+	}
 
 	if debug {
 		man.SetDebugDump(true)
@@ -342,7 +478,313 @@ func (cmd *CmdOptions) Init(man *ManPso) {
 		fmt.Println(man.ActDescription())
 		done = true
 	}
+	if listSched {
+		fmt.Println(man.ScheduleDescription())
+		done = true
+	}
 	if done {
 		os.Exit(0)
 	}
 }
+
+/*
+Checkpoint implements the Action, checkpoint. At each data output it saves the
+state of the SPSO together with the run position to the file
+
+	checkpoint<run ID>.gob
+
+At the start of a run it looks for this file and if it exists restores the SPSO
+state and carries on the run from where it was saved. The file is removed at
+the end of the run. The SPSO has to support setpso.Checkpointer otherwise
+nothing is saved.
+*/
+type Checkpoint struct{}
+
+// checkpointData is the content of a checkpoint file.
+type checkpointData struct {
+	PsoCase string
+	FunCase string
+	Iter    int
+	Diter   int
+	Evals   [3]int64
+	Swarm   []byte
+}
+
+// Checkpoint writes the checkpoint data to w.
+func (d *checkpointData) Checkpoint(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(d)
+}
+
+// Restore reads back checkpoint data written by Checkpoint() from r.
+func (d *checkpointData) Restore(r io.Reader) error {
+	return gob.NewDecoder(r).Decode(d)
+}
+
+func checkpointFilename(man *ManPso) string {
+	return fmt.Sprintf("checkpoint%d.gob", man.RunID())
+}
+
+//RunInit restores the run from its checkpoint file if there is one.
+func (a *Checkpoint) RunInit(man *ManPso) {
+	c, ok := man.P().(setpso.Checkpointer)
+	if !ok {
+		fmt.Printf("SPSO %s does not support checkpoints\n", man.PsoCase())
+		return
+	}
+	filename := checkpointFilename(man)
+	if _, err := os.Stat(filename); err != nil {
+		// no checkpoint so start from the beginning
+		return
+	}
+	var data checkpointData
+	if err := setpso.RestoreFile(&data, filename); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if data.PsoCase != man.PsoCase() || data.FunCase != man.FunCase() {
+		fmt.Printf("checkpoint for %s with %s ignored\n", data.PsoCase, data.FunCase)
+		return
+	}
+	if err := c.Restore(bytes.NewReader(data.Swarm)); err != nil {
+		fmt.Println(err)
+		return
+	}
+	man.iter = data.Iter
+	man.diter = data.Diter
+	man.evals.Set(data.Evals[0], data.Evals[1], data.Evals[2])
+	fmt.Printf("Run %d restored at iteration %d\n", man.RunID(), man.Iter())
+}
+
+//DataUpdate saves the checkpoint file.
+func (a *Checkpoint) DataUpdate(man *ManPso) {
+	c, ok := man.P().(setpso.Checkpointer)
+	if !ok {
+		return
+	}
+	var data checkpointData
+	data.PsoCase = man.PsoCase()
+	data.FunCase = man.FunCase()
+	data.Iter = man.Iter()
+	// the data output for this Diter() has been done
+	data.Diter = man.Diter() + 1
+	data.Evals[0], data.Evals[1], data.Evals[2] = man.evals.Counts()
+	var swarm bytes.Buffer
+	if err := c.Checkpoint(&swarm); err != nil {
+		fmt.Println(err)
+		return
+	}
+	data.Swarm = swarm.Bytes()
+	if err := setpso.CheckpointFile(&data, checkpointFilename(man)); err != nil {
+		fmt.Println(err)
+	}
+}
+
+//Result removes the checkpoint file once the run has finished.
+func (a *Checkpoint) Result(man *ManPso) {
+	os.Remove(checkpointFilename(man))
+}
+
+/*
+PrintElite implements the Action, print-elite. At the end of a run it prints
+the cost and decoded subset of each try in the elite archive of the SPSO, set
+up using SetEliteArchive(), best first.
+*/
+type PrintElite struct{}
+
+//Result prints the elite archive.
+func (a *PrintElite) Result(man *ManPso) {
+	ar, ok := man.P().(setpso.Archiver)
+	if !ok || len(ar.Elite()) == 0 {
+		fmt.Printf("RUN %d has no elite archive\n", man.RunID())
+		return
+	}
+	fmt.Printf("RUN %d elite archive:\n", man.RunID())
+	for k, t := range ar.Elite() {
+		fmt.Printf(" %d Cost: %s\n%s\n", k, t.Cost(), t.Decode())
+	}
+}
+
+/*
+PrintNiches implements the Action, print-niches. At the end of a run it prints
+the number of particles, best cost and decoded best subset of each niche found
+by a niching SPSO such as setpso.NPso, best first. It does nothing for other
+SPSOs.
+*/
+type PrintNiches struct{}
+
+//Result prints the niches.
+func (a *PrintNiches) Result(man *ManPso) {
+	p, ok := man.P().(*setpso.NPso)
+	if !ok {
+		return
+	}
+	ns := p.Niches()
+	fmt.Printf("RUN %d found %d niches:\n", man.RunID(), len(ns))
+	for k, n := range ns {
+		try := p.LocalBestTry(n.Best)
+		fmt.Printf(" %d Particles: %d Cost: %s\n%s\n", k, len(n.Members), try.Cost(), try.Decode())
+	}
+}
+
+/*
+PrintFront implements the Action, print-front. At the end of a run it prints
+the costs and decoded subset of each try in the Pareto front found by a
+multi-objective SPSO such as setpso.MOPso in order of increasing costs, first
+objective first. It does nothing for other SPSOs.
+*/
+type PrintFront struct{}
+
+//Result prints the front.
+func (a *PrintFront) Result(man *ManPso) {
+	p, ok := man.P().(setpso.Fronter)
+	if !ok {
+		return
+	}
+	front := p.Front()
+	fmt.Printf("RUN %d found %d non-dominated solutions:\n", man.RunID(), len(front))
+	for k, t := range front {
+		fmt.Printf(" %d Cost: %s\n%s\n", k, t.Cost(), t.Decode())
+	}
+}
+
+/*
+PlotFront implements the Action, plot-front. At the end of a run it plots the
+first cost against the second cost of each try in the Pareto front found by a
+multi-objective SPSO such as setpso.MOPso and puts it into the file of the
+form:
+
+	plotFront<run ID>.pdf
+
+It does nothing for other SPSOs or cost-functions with fewer than two costs.
+*/
+type PlotFront struct{}
+
+//Result generates the plot of the front.
+func (a *PlotFront) Result(man *ManPso) {
+	p, ok := man.P().(setpso.Fronter)
+	if !ok {
+		return
+	}
+	var pts plotter.XYs
+	for _, t := range p.Front() {
+		v, ok := t.(interface{ CostVector() []float64 })
+		if !ok || len(v.CostVector()) < 2 {
+			return
+		}
+		c := v.CostVector()
+		pts = append(pts, plotter.XY{X: c[0], Y: c[1]})
+	}
+	pl, err := plot.New()
+	if err != nil {
+		panic(err)
+	}
+	pl.Add(plotter.NewGrid())
+	sc, err := plotter.NewScatter(pts)
+	if err != nil {
+		panic(err)
+	}
+	pl.Add(sc)
+	pl.Title.Text = fmt.Sprintf("Pareto front: Run %d", man.RunID())
+	pl.X.Label.Text = "cost 0"
+	pl.Y.Label.Text = "cost 1"
+	filename := fmt.Sprintf("plotFront%d.pdf", man.RunID())
+	if err := pl.Save(4*vg.Inch, 4*vg.Inch, filename); err != nil {
+		panic(err)
+	}
+}
+
+/*
+PlotEntropy implements the Action, plot-entropy. It plots the entropy of the
+probability model of an optimizer such as setpso.PBIL during a run and puts it
+into the file of the form:
+
+	plotEntropy<run ID>.pdf
+
+It does nothing for optimizers without a model.
+*/
+type PlotEntropy struct {
+	pts plotter.XYs
+}
+
+//RunInit clears the plotting points for the run.
+func (a *PlotEntropy) RunInit(man *ManPso) {
+	a.pts = a.pts[:0]
+}
+
+//DataUpdate adds the entropy of the model to the plot.
+func (a *PlotEntropy) DataUpdate(man *ManPso) {
+	p, ok := man.P().(setpso.Entropier)
+	if !ok {
+		return
+	}
+	a.pts = append(a.pts, plotter.XY{X: float64(man.Iter()), Y: p.Entropy()})
+}
+
+//Result generates the plot of the entropy.
+func (a *PlotEntropy) Result(man *ManPso) {
+	if len(a.pts) == 0 {
+		return
+	}
+	pl, err := plot.New()
+	if err != nil {
+		panic(err)
+	}
+	pl.Add(plotter.NewGrid())
+	line, err := plotter.NewLine(a.pts)
+	if err != nil {
+		panic(err)
+	}
+	pl.Add(line)
+	pl.Title.Text = fmt.Sprintf("Model entropy: Run %d", man.RunID())
+	pl.X.Label.Text = "iteration"
+	pl.Y.Label.Text = "entropy (bits)"
+	filename := fmt.Sprintf("plotEntropy%d.pdf", man.RunID())
+	if err := pl.Save(4*vg.Inch, 4*vg.Inch, filename); err != nil {
+		panic(err)
+	}
+}
+
+/*
+AdaptLog implements the Action, adapt-log. For an adaptive SPSO such as
+setpso.APso it writes each adaption of the group heuristics to the file
+adapt<runid>.txt as it is made and prints the heuristics of the group
+containing the global best at the end of the run so they can be reused. It
+does nothing for other SPSOs.
+*/
+type AdaptLog struct {
+	f *os.File
+}
+
+// RunInit opens the log file and attaches it to the SPSO.
+func (a *AdaptLog) RunInit(man *ManPso) {
+	p, ok := man.P().(*setpso.APso)
+	if !ok {
+		return
+	}
+	f, err := os.Create(fmt.Sprintf("adapt%d.txt", man.RunID()))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Fprintf(f, "# %s\n# update group success float-heuristics int-heuristics\n", p.Rule().About())
+	p.SetLog(f)
+	a.f = f
+}
+
+// Result closes the log file and prints the best group's heuristics.
+func (a *AdaptLog) Result(man *ManPso) {
+	p, ok := man.P().(*setpso.APso)
+	if !ok {
+		return
+	}
+	p.SetLog(nil)
+	if a.f != nil {
+		a.f.Close()
+		a.f = nil
+	}
+	hu := p.BestHeuristics()
+	fmt.Printf("RUN %d adapted heuristics of best group:\n", man.RunID())
+	fmt.Printf(" phi=%g omega=%g lfactor=%g loffset=%g\n",
+		hu.Float(setpso.PhiHeuristic), hu.Float(setpso.OmegaHeuristic),
+		hu.Float(setpso.LfactorHeuristic), hu.Float(setpso.LoffsetHeuristic))
+}
@@ -21,6 +21,16 @@ the inbuilt components at a later date. In this way a list of ready made
 components can be built up to deepen the test space for  competing algorithms
 and monitoring methods.
 
+The SPSO's cost-function calls are counted so runs can be compared by the
+number of evaluations as well as by iterations; SetEvalBudget() ends each run
+once a given number of evaluations have been used. The counts include the
+evaluations of any local search chosen with SelectLocalSearch() and the extra
+samples of any racing of noisy costs chosen with SelectRacer(). The baselines
+bpso-0, ga-0 and sa-0 can be selected in place of an SPSO to compare it with a
+binary PSO, a genetic algorithm and simulated annealing on the same budget, and
+pbil-0 compares it with an estimation of distribution algorithm whose model
+entropy is plotted by the plot-entropy Action.
+
 An example of its use is given in the setpso subdirectory
     setpso/example/runkit1
 This includes the command line option reader Action. When run without arguments
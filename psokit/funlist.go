@@ -6,6 +6,7 @@ import (
 	"math/big"
 	"sort"
 
+	"github.com/mathrgo/setpso/fun/poolsum"
 	"github.com/mathrgo/setpso/fun/simplefactor"
 	"github.com/mathrgo/setpso/fun/subsetsum"
 )
@@ -34,12 +35,32 @@ If cost-function not found the the event is logged and it returns nil otherwise
 it sets up man to use the cost-function.
 */
 func (man *ManPso) CreateFun(name string) (f Fun) {
+	f = man.newFun(name)
+	if f != nil {
+		man.f = f
+		man.funCase = name
+	}
+	return
+}
+
+/*
+newFun returns a new cost-function instance based on its name using the seed
+for the current run without setting man up to use it. This is also used to give
+each SPSO worker its own instance of the cost-function.
+*/
+func (man *ManPso) newFun(name string) (f Fun) {
 	// calculate  cost function seed for the run
 	fsd := man.funSeed1*int64(man.runid) + man.funSeed0
 	switch name {
 	case "subsetsum-0":
 		// basic subset sum case
 		f = subsetsum.New(100, 20, fsd)
+	case "poolsum-0":
+		// subset sum with slot values replaced from a pool
+		f = poolsum.New(100, 300, 20, fsd)
+	case "subsetsum-mo-0":
+		// subset sum trading off the sum error against the subset size
+		f = subsetsum.NewMO(100, 20, fsd)
 	case "simplefactor-30":
 		// use this to show that the prime factorisation is still not easy
 		var p, q,pMin big.Int
@@ -66,13 +87,8 @@ func (man *ManPso) CreateFun(name string) (f Fun) {
 		} else {
 			f = nil
 			log.Printf("Cost function creator %s not found", name)
-			return
 		}
 	}
-	if f != nil {
-		man.f = f
-		man.funCase = name
-	}
 	return
 }
 
@@ -84,6 +100,8 @@ func (man *ManPso) loadFunDescription() {
 
 	man.fund = map[string]string{
 		"subsetsum-0":     "basic subset sum case 100 elements with up to 20 bit int",
+		"poolsum-0":       "subset sum of 100 slots holding values from a pool of 300 up to 20 bit int that are replaced when unused",
+		"subsetsum-mo-0":  "two objective subset sum of 100 elements trading off the sum error against the number of elements; for multi-objective SPSOs",
 		"simplefactor-30": "30 bit prime factorisation",
 		"simplefactor-25": "25 bit prime factorisation",
 		"simplefactor-16": "16 bit prime factorisation"}
@@ -0,0 +1,70 @@
+package psokit
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/mathrgo/setpso"
+)
+
+/*
+SelectInit selects by name the strategy used to choose the initial Parameters
+of the particles. It returns an error if the name is not one of:
+
+	random      random until the constraints are satisfied (the default)
+	budget      random with at most InitBudget() attempts for each particle
+	            before giving up the run
+	fallback    random with at most InitBudget() attempts for each particle
+	            before using the cost-function's default
+	opposition  the best of random tries and their opposites
+	maximin     spread out by maximin Hamming distance from 10 random tries
+
+All but random use InitBudget() to limit attempts to satisfy constraints. When
+the limit is reached budget gives up the run while the others fall back to the
+cost-function's default.
+*/
+func (man *ManPso) SelectInit(name string) error {
+	if man.newInit(name) == nil {
+		return fmt.Errorf("the initialization strategy %s could not be found", name)
+	}
+	man.initCase = name
+	return nil
+}
+
+// InitCase returns the name of the initialization strategy in use.
+func (man *ManPso) InitCase() string { return man.initCase }
+
+// SetInitBudget sets the attempt budget used by the initialization strategies.
+func (man *ManPso) SetInitBudget(n int) { man.initBudget = n }
+
+// InitBudget returns the attempt budget used by the initialization strategies.
+func (man *ManPso) InitBudget() int { return man.initBudget }
+
+/*
+SetInitSeeds sets Parameters for the first particles to start from, one each,
+with the rest chosen by the selected strategy. The seeds must satisfy the
+cost-function constraints.
+*/
+func (man *ManPso) SetInitSeeds(seeds ...*big.Int) { man.initSeeds = seeds }
+
+// newInit returns the initialization strategy by name or nil if not found.
+func (man *ManPso) newInit(name string) (s setpso.Initializer) {
+	switch name {
+	case "random":
+		s = &setpso.RandomInit{}
+	case "budget":
+		s = &setpso.RandomInit{Budget: man.initBudget}
+	case "fallback":
+		s = &setpso.RandomInit{Budget: man.initBudget, Fallback: true}
+	case "opposition":
+		s = &setpso.OppositionInit{Budget: man.initBudget, Fallback: true}
+	case "maximin":
+		s = &setpso.MaximinInit{Candidates: 10, Budget: man.initBudget, Fallback: true}
+	default:
+		return nil
+	}
+	if len(man.initSeeds) > 0 {
+		s = &setpso.SeededInit{Seeds: man.initSeeds, Rest: s}
+	}
+	return s
+}
@@ -2,6 +2,7 @@ package psokit
 
 import (
 	"fmt"
+	"log"
 	"math/big"
 	"time"
 
@@ -100,6 +101,12 @@ type ManPso struct {
 	psod map[string]string
 	// pointers to added SPSO instance creator
 	addedPso map[string]CreatePso
+	// schedule descriptions
+	schedd map[string]string
+	// pointers to added schedule creators
+	addedSched map[string]CreateSchedule
+	// schedule name by heuristic name
+	schedules map[string]string
 
 	// description of Actions by name
 	actd map[string]string
@@ -134,6 +141,46 @@ type ManPso struct {
 	nrun int
 	// number of particles in SPSO instance
 	npart int
+	// number of workers used to evaluate particle costs concurrently
+	nworker int
+	// name of the operation used to combine velocity probabilities
+	combiner string
+	// name of the initialization strategy
+	initCase string
+	// attempt budget of the initialization strategy
+	initBudget int
+	// Parameters for the first particles to start from
+	initSeeds []*big.Int
+	// item replacement window; 0 when item replacement is not used
+	replaceWindow int
+	// item usage threshold for replacement
+	replaceThreshold float64
+	// sparse velocity cut off; 0 for dense velocities
+	sparseEps float64
+	// counter of cost-function evaluations made by the SPSO during a run
+	evals *setpso.EvalCounter
+	// evaluation budget of a run; 0 for no budget
+	evalBudget int64
+	// number of tries in each cost cache; 0 for no cache
+	cacheSize int
+	// cost caches in use during a run
+	caches []*setpso.CachingFun
+	// restart policy; nil when restarts are not used
+	restart *setpso.RestartPolicy
+	// elite archive size; 0 when the archive is not used
+	eliteSize int
+	// minimum Hamming distance between elite archive entries
+	eliteDist int
+	// name of the local search
+	lsCase string
+	// number of updates between local searches
+	lsPeriod int
+	// number of best Personal-bests searched
+	lsTopK int
+	// name of the racing of noisy tries
+	raceCase string
+	// number of extra cost samples drawn by racing each update
+	raceBudget int
 	// cost function seed gain against run id
 	funSeed1 int64
 	// cost function seed offset
@@ -157,6 +204,10 @@ func NewMan() *ManPso {
 	man.psod = make(map[string]string)
 	man.addedPso = make(map[string]CreatePso)
 	man.loadPsoDescription()
+	man.schedd = make(map[string]string)
+	man.addedSched = make(map[string]CreateSchedule)
+	man.schedules = make(map[string]string)
+	man.loadScheduleDescription()
 	man.actd = make(map[string]string)
 	man.addedAct = make(map[string]Act)
 	man.actInit = make([]ActInit, 0, 10)
@@ -172,6 +223,18 @@ func NewMan() *ManPso {
 	man.nthink = 300
 	man.nrun = 1
 	man.npart = 10
+	man.nworker = 1
+	man.combiner = "pseudo-add"
+	man.replaceThreshold = 0.02
+	man.initCase = "random"
+	man.initBudget = 1000
+	man.evals = new(setpso.EvalCounter)
+	man.eliteDist = 1
+	man.lsCase = "none"
+	man.lsPeriod = 10
+	man.raceCase = "none"
+	man.raceBudget = 40
+	man.lsTopK = 1
 	man.funSeed1 = 0
 	man.funSeed0 = 3142
 	man.psoSeed1 = 34
@@ -186,6 +249,9 @@ explicitly called; it is  exportable for those that need it. For instance it is
 used in some test examples where there is no need to run a case.
 */
 func (man *ManPso) Init() {
+	man.p = nil
+	man.evals.Reset()
+	man.caches = nil
 	man.CreateFun(man.funCase)
 	man.CreatePso(man.psoCase)
 }
@@ -209,6 +275,46 @@ func (man *ManPso) String() string {
 	}
 	s += fmt.Sprintf("Number of Runs = %d \t", man.nrun)
 	s += fmt.Sprintf("Number of Particles = %d\n", man.npart)
+	if man.nworker > 1 {
+		s += fmt.Sprintf("Number of cost evaluation workers = %d\n", man.nworker)
+	}
+	if man.combiner != "pseudo-add" {
+		s += fmt.Sprintf("Probability combiner = %s\n", man.combiner)
+	}
+	if man.initCase != "random" || len(man.initSeeds) > 0 {
+		s += fmt.Sprintf("Initialization = %s with budget %d and %d seeds\n",
+			man.initCase, man.initBudget, len(man.initSeeds))
+	}
+	if man.replaceWindow > 0 {
+		s += fmt.Sprintf("Item replacement window = %d threshold = %g\n",
+			man.replaceWindow, man.replaceThreshold)
+	}
+	if man.evalBudget > 0 {
+		s += fmt.Sprintf("Evaluation budget = %d\n", man.evalBudget)
+	}
+	if man.eliteSize > 0 {
+		s += fmt.Sprintf("Elite archive size = %d distance = %d\n", man.eliteSize, man.eliteDist)
+	}
+	if r := man.restart; r != nil {
+		s += fmt.Sprintf("Restart after %d updates without improvement at diversity %g of %g with %d elite\n",
+			r.MaxAge, r.MinDiversity, r.Fraction, r.Elite)
+	}
+	if man.lsCase != "none" {
+		s += fmt.Sprintf("Local search = %s every %d updates on the best %d\n",
+			man.lsCase, man.lsPeriod, man.lsTopK)
+	}
+	if man.raceCase != "none" {
+		s += fmt.Sprintf("Racing = %s with %d samples each update\n", man.raceCase, man.raceBudget)
+	}
+	if man.cacheSize > 0 {
+		s += fmt.Sprintf("Cost cache size = %d\n", man.cacheSize)
+	}
+	if man.sparseEps > 0 {
+		s += fmt.Sprintf("Sparse velocity cut off = %g\n", man.sparseEps)
+	}
+	if len(man.schedules) > 0 {
+		s += fmt.Sprintf("Heuristic schedules = %s\n", man.Schedules())
+	}
 	s += fmt.Sprintf("Max number of data coms in a run = %d\n", man.datalength)
 	s += fmt.Sprintf("Thinking interval between data coms = %d\n", man.nthink)
 	s += fmt.Sprintf("funSeed=%d + runid*%d\t", man.funSeed0, man.funSeed1)
@@ -277,6 +383,111 @@ func (man *ManPso) SetNpart(n int) { man.npart = n }
 //Npart returns number of particles in SPSO instance.
 func (man *ManPso) Npart() int { return man.npart }
 
+/*
+SetNworker sets the number of workers used to evaluate particle costs
+concurrently during each update. A value of 1 evaluates costs serially.
+*/
+func (man *ManPso) SetNworker(n int) { man.nworker = n }
+
+//Nworker returns the number of workers used to evaluate particle costs.
+func (man *ManPso) Nworker() int { return man.nworker }
+
+/*
+SetItemReplacement sets the SPSO to replace items through the cost-function's
+Delete() when their usage by Personal-bests over window updates falls below
+threshold; window = 0 turns this off, which is the default.
+*/
+func (man *ManPso) SetItemReplacement(window int, threshold float64) {
+	man.replaceWindow = window
+	man.replaceThreshold = threshold
+}
+
+//ItemReplacement returns the item replacement window and threshold.
+func (man *ManPso) ItemReplacement() (window int, threshold float64) {
+	return man.replaceWindow, man.replaceThreshold
+}
+
+/*
+Evaluations returns the number of cost-function evaluations made by the SPSO so
+far in the run, counting the SetTry(), UpdateCost() and ToConstraint() calls of
+the SPSO and its workers.
+*/
+func (man *ManPso) Evaluations() int64 { return man.evals.Evaluations() }
+
+/*
+SetEvalBudget sets the number of cost-function evaluations after which a run
+ends; the run ends after the update that uses up the budget and is given a
+final data output. n = 0 gives no budget, which is the default, so only
+Datalength()*Nthink() iterations limit the run. When a run ends early Diter()
+is the number of data outputs made.
+*/
+func (man *ManPso) SetEvalBudget(n int64) { man.evalBudget = n }
+
+//EvalBudget returns the evaluation budget of a run.
+func (man *ManPso) EvalBudget() int64 { return man.evalBudget }
+
+/*
+SetEliteArchive sets the SPSO to keep an archive of up to size of the best
+Personal-bests found whose Parameters differ in at least minDist bits, which
+can be printed using the print-elite Action; size = 0 turns this off, which is
+the default.
+*/
+func (man *ManPso) SetEliteArchive(size, minDist int) {
+	man.eliteSize = size
+	man.eliteDist = minDist
+}
+
+//EliteArchive returns the elite archive size and minimum distance.
+func (man *ManPso) EliteArchive() (size, minDist int) { return man.eliteSize, man.eliteDist }
+
+/*
+SetRestart sets the SPSO to restart part of the swarm when it is stagnant using
+policy; nil turns this off, which is the default.
+*/
+func (man *ManPso) SetRestart(policy *setpso.RestartPolicy) { man.restart = policy }
+
+//Restart returns the restart policy or nil if restarts are not used.
+func (man *ManPso) Restart() *setpso.RestartPolicy { return man.restart }
+
+/*
+SetCostCache sets the SPSO and its workers to each cache the costs of up to n
+tries by parameter using setpso.CachingFun, which suits deterministic
+cost-functions; n = 0 turns this off, which is the default. Cost-functions
+with noisy costs are not cached.
+*/
+func (man *ManPso) SetCostCache(n int) { man.cacheSize = n }
+
+//CostCache returns the number of tries in each cost cache.
+func (man *ManPso) CostCache() int { return man.cacheSize }
+
+/*
+CacheHitRate returns the fraction of cost cache lookups during the run that
+found the parameter in a cache, taken over all the caches, or 0 when there are
+no lookups.
+*/
+func (man *ManPso) CacheHitRate() float64 {
+	var hits, misses int64
+	for _, c := range man.caches {
+		h, m := c.Lookups()
+		hits += h
+		misses += m
+	}
+	if hits+misses == 0 {
+		return 0.0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+/*
+SetSparseVelocity sets the SPSO to use sparse velocities with the cut off eps,
+which suits very large parameter spaces; eps = 0 gives dense velocities, which
+is the default.
+*/
+func (man *ManPso) SetSparseVelocity(eps float64) { man.sparseEps = eps }
+
+//SparseVelocity returns the sparse velocity cut off.
+func (man *ManPso) SparseVelocity() float64 { return man.sparseEps }
+
 /*
 PsoSeed returns the random generator seed components of SPSO
 where seed=sd0+sd1*RunId().
@@ -321,17 +532,28 @@ func (man *ManPso) Run() {
 	for man.runid = 0; man.runid < man.nrun; man.runid++ {
 		start := time.Now()
 		man.iter = 0
+		man.diter = 0
 		man.Init()
+		if man.p == nil {
+			log.Fatalf("run %d has no SPSO", man.runid)
+		}
+		// this may move the run on, for instance when restoring a checkpoint
 		for i := range man.actRunInit {
 			man.actRunInit[i].RunInit(man)
 		}
-		for man.diter = 0; man.diter < man.datalength; man.diter++ {
+		stop := false
+		for ; man.diter < man.datalength && !stop; man.diter++ {
 			for man.thinkiteration = 0; man.thinkiteration < man.nthink; man.thinkiteration++ {
 				man.p.Update()
 				for i := range man.actUpdate {
 					man.actUpdate[i].Update(man)
 				}
 				man.iter++
+				if man.evalBudget > 0 && man.Evaluations() >= man.evalBudget {
+					// finish with a data output for the partial interval
+					stop = true
+					break
+				}
 			}
 			for i := range man.actData {
 				man.actData[i].DataUpdate(man)
@@ -2,6 +2,7 @@ package psokit
 
 import (
 	"fmt"
+	"testing"
 
 	"github.com/mathrgo/setpso/fun/subsetsum"
 )
@@ -68,3 +69,34 @@ func ExampleManPso_SelectActs() {
 	/* Output:
 	 */
 }
+
+func TestEvalBudget(t *testing.T) {
+	man := NewMan()
+	man.Setdatalength(100)
+	man.SetNthink(10)
+	man.SetEvalBudget(2000)
+	man.Run()
+	if man.Evaluations() < man.EvalBudget() {
+		t.Errorf("run ended after %d evaluations before the budget %d", man.Evaluations(), man.EvalBudget())
+	}
+	if man.Diter() >= man.Datalength() {
+		t.Errorf("run did not end early")
+	}
+	// the budget is used up by the last update
+	if man.Evaluations() > man.EvalBudget()+int64(10*man.Npart()) {
+		t.Errorf("run went on to %d evaluations", man.Evaluations())
+	}
+}
+
+func TestCreateCCPso(t *testing.T) {
+	man := NewMan()
+	if err := man.SelectPso("ccpso-0"); err != nil {
+		t.Fatal(err)
+	}
+	man.Init()
+	// only the 4 sub-swarms are initialized; no Pso is built for the whole
+	// cost-function
+	if n := man.Evaluations(); n != int64(4*man.Npart()) {
+		t.Errorf("initializing ccpso-0 took %d evaluations, expected %d", n, 4*man.Npart())
+	}
+}
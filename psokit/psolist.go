@@ -6,6 +6,7 @@ import (
 	"sort"
 
 	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/futil"
 )
 
 /*
@@ -27,18 +28,95 @@ CreatePso  returns the SPSO instance based on its name and is called by man at
 the beginning of a run, so there is no need to call this if using man to execute
 the run sequence;  you can use SelectPso() instead.
 
-If SPSO is not found the the event is logged and it returns nil otherwise it
+If SPSO is not found or the initialization strategy chosen with SelectInit()
+fails the the event is logged and it returns nil otherwise it
 sets up man to use it . This also assumes the cost-function has been created for
-man beforehand using CreateFun().
+man beforehand using CreateFun(). When Nworker() > 1 each worker evaluating
+particle costs concurrently is given its own cost-function instance created
+from the same cost-function name and seed. Heuristic schedules chosen with
+SetSchedule() are attached to the master heuristics of the SPSO.
 */
 func (man *ManPso) CreatePso(name string) (p PsoInterface) {
-	p0 := setpso.NewPso(man.npart, man.f, man.psoSeed0+man.psoSeed1*int64(man.runid))
+	var err error
+	if name == "ccpso-0" {
+		// the sub-swarms are built on Psos of their own
+		p, err = man.newCCPso()
+	} else {
+		p, err = man.newOnPso(name)
+	}
+	if err != nil {
+		log.Printf("PSO %s could not be initialized: %v", name, err)
+		return nil
+	}
+	if p != nil {
+		man.p = p
+		man.psoCase = name
+	}
+	return
+}
+
+// newOnPso returns the SPSO instance name, other than ccpso-0, built on a Pso
+// created by newPso() for the cost-function of man. It logs and returns nil
+// when name is not found.
+func (man *ManPso) newOnPso(name string) (p PsoInterface, err error) {
+	p0, err := man.newPso(man.f, man.psoSeed0+man.psoSeed1*int64(man.runid))
+	if err != nil {
+		return nil, err
+	}
 	switch name {
 	case "gpso-0":
 		p = setpso.NewGPso(p0)
 	case "clpso-0":
 
 		p = setpso.NewCLPso(p0)
+	case "clpso-1":
+		p = setpso.NewCLPso1(p0, 4)
+	case "lpso-0":
+		p = setpso.NewLPso(p0, &setpso.RingTopology{K: 1})
+	case "lpso-1":
+		p = setpso.NewLPso(p0, &setpso.VonNeumannTopology{})
+	case "lpso-2":
+		p = setpso.NewLPso(p0, &setpso.RandomTopology{K: 3,
+			Period: p0.Heuristics().Int(setpso.TryGapHeuristic)})
+	case "fipso-0":
+		p = setpso.NewFIPso(p0, &setpso.VonNeumannTopology{}, setpso.RankWeights)
+	case "islands-0":
+		is, err := man.newIslands(p0)
+		if err != nil {
+			return nil, err
+		}
+		p = is
+	case "npso-0":
+		radius := man.f.MaxLen() / 10
+		if radius < 2 {
+			radius = 2
+		}
+		p = setpso.NewNPso(p0, radius, p0.Heuristics().Int(setpso.TryGapHeuristic))
+	case "mopso-0":
+		mo, err := setpso.NewMOPso(p0, 4, 50, setpso.CrowdingLeaders{})
+		if err != nil {
+			return nil, err
+		}
+		p = mo
+	case "bpso-0":
+		p = setpso.NewBPso(p0)
+	case "ga-0":
+		p = setpso.NewGA(p0)
+	case "sa-0":
+		p = setpso.NewSA(p0)
+	case "pbil-0":
+		p = setpso.NewPBIL(p0, 2, 0.1)
+	case "apso-0":
+		p = setpso.NewAPso(p0, 4, &setpso.OneFifthRule{
+			Ranges: []setpso.HeuristicRange{
+				{Index: setpso.PhiHeuristic, Low: 0.5, High: 2.0},
+				{Index: setpso.LfactorHeuristic, Low: 0.02, High: 0.6}},
+			Factor: 1.2, Target: 0.2}, 20)
+	case "apso-1":
+		p = setpso.NewAPso(p0, 4, &setpso.BanditRule{
+			Indexes: []int{setpso.OmegaHeuristic, setpso.LfactorHeuristic},
+			Arms:    [][]float64{{0.6, 0.1}, {0.73, 0.15}, {0.85, 0.2}, {0.73, 0.3}},
+			C:       0.1}, 20)
 	default:
 		pc := man.addedPso[name]
 		if pc != nil {
@@ -49,13 +127,148 @@ func (man *ManPso) CreatePso(name string) (p PsoInterface) {
 			log.Printf("PSO %s not found", name)
 		}
 	}
-	if p != nil {
-		man.p = p
-		man.psoCase = name
-	}
 	return
 }
 
+// newPso returns a Pso using the cost function f and seed sd set up with the
+// initialization strategy, combiner, schedules, item replacement, elite
+// archive, restarts, sparse velocities, local search, racing and workers chosen
+// for man. The cost functions of the Pso and its workers are wrapped by
+// wrapFun().
+func (man *ManPso) newPso(f Fun, sd int64) (*setpso.Pso, error) {
+	p0, err := man.setupPso(man.wrapFun(f), sd)
+	if err != nil {
+		return nil, err
+	}
+	if man.nworker > 1 {
+		funCase := man.funCase
+		err = p0.SetWorkers(man.nworker, func() setpso.Fun {
+			return man.wrapFun(man.newFun(funCase))
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p0, nil
+}
+
+// setupPso returns a Pso using the cost function cf, which is used as it is,
+// and seed sd set up as for newPso() apart from the workers.
+func (man *ManPso) setupPso(cf setpso.Fun, sd int64) (*setpso.Pso, error) {
+	p0, err := setpso.NewPsoInit(man.npart, cf, sd, man.newInit(man.initCase))
+	if err != nil {
+		return nil, err
+	}
+	p0.SetCombiner(man.newCombiner(man.combiner))
+	man.applySchedules(p0)
+	p0.SetItemReplacement(man.replaceWindow, man.replaceThreshold)
+	p0.SetEliteArchive(man.eliteSize, man.eliteDist)
+	p0.SetRestart(man.restart)
+	p0.SetSparseVelocity(man.sparseEps)
+	ls, _ := man.newLocalSearch(man.lsCase)
+	p0.SetLocalSearch(ls, man.lsPeriod, man.lsTopK)
+	r, _ := man.newRacer(man.raceCase)
+	if err = p0.SetRacing(r, man.raceBudget); err != nil {
+		return nil, err
+	}
+	return p0, nil
+}
+
+/*
+wrapFun wraps f to count evaluations with man's counter and when CostCache() >
+0 to cache costs, counting only the evaluations that miss the cache. Cost
+functions that cannot be cached are used without a cache.
+*/
+func (man *ManPso) wrapFun(f Fun) setpso.Fun {
+	var w setpso.Fun = setpso.NewCountingFun(f, man.evals)
+	if man.cacheSize > 0 {
+		c, err := setpso.NewCachingFun(w, man.cacheSize)
+		if err != nil {
+			log.Printf("cost cache not used: %v", err)
+			return w
+		}
+		man.caches = append(man.caches, c)
+		w = c
+	}
+	return w
+}
+
+/*
+newIslands returns the islands used by islands-0. The first island is a GPso
+built on p0 and the other three are a CLPso, LPso and FIPso each with their own
+cost-function instance and seed. Each island has Npart() particles. Item
+replacement is turned off by setpso.NewIslands() so -replace has no effect.
+*/
+func (man *ManPso) newIslands(p0 *setpso.Pso) (*setpso.Islands, error) {
+	period := p0.Heuristics().Int(setpso.TryGapHeuristic)
+	seed := man.psoSeed0 + man.psoSeed1*int64(man.runid)
+	islands := []setpso.Island{setpso.NewGPso(p0)}
+	for k := int64(1); k < 4; k++ {
+		pk, err := man.newPso(man.newFun(man.funCase), seed+1000*k)
+		if err != nil {
+			return nil, err
+		}
+		switch k {
+		case 1:
+			islands = append(islands, setpso.NewCLPso(pk))
+		case 2:
+			islands = append(islands, setpso.NewLPso(pk, &setpso.RingTopology{K: 1}))
+		case 3:
+			islands = append(islands, setpso.NewFIPso(pk, &setpso.VonNeumannTopology{}, setpso.RankWeights))
+		}
+	}
+	is := setpso.NewIslands(&setpso.RingTopology{K: 1}, period, 1, seed, islands...)
+	is.Concurrent = true
+	return is, nil
+}
+
+/*
+newCCPso returns the CCPso used by ccpso-0. The parameter bits are split into
+the parts of the cost-function's futil.Splitter when it has one and otherwise
+into 4 equal ranges. Each range is searched by a GPso of Npart() particles with
+its own seed. The sub-swarms share one cost-function instance wrapped by
+wrapFun() so workers are not used.
+*/
+func (man *ManPso) newCCPso() (*setpso.CCPso, error) {
+	seed := man.psoSeed0 + man.psoSeed1*int64(man.runid)
+	var ranges []setpso.BitRange
+	if sp := splitterOf(man.f); sp != nil {
+		ranges = setpso.SplitterRanges(sp)
+	} else {
+		ranges = setpso.EqualRanges(man.f.MaxLen(), 4)
+	}
+	return setpso.NewCCPso(man.wrapFun(man.f), ranges,
+		func(k int, pf *setpso.PartFun) (setpso.Island, error) {
+			pk, err := man.setupPso(pf, seed+1000*int64(k))
+			if err != nil {
+				return nil, err
+			}
+			return setpso.NewGPso(pk), nil
+		})
+}
+
+// splitterOf returns the futil.Splitter of the cost-function f, or of the
+// cost-function wrapped by its stub, and nil if it has none.
+func splitterOf(f Fun) *futil.Splitter {
+	var g interface{} = f
+	switch s := f.(type) {
+	case *futil.IntFunStub:
+		g = s.Fun()
+	case *futil.FloatFunStub:
+		g = s.Fun()
+	case *futil.SFloatFunStub:
+		g = s.Fun()
+	case *futil.VFloatFunStub:
+		g = s.Fun()
+	case *futil.VecFunStub:
+		g = s.Fun()
+	}
+	if s, ok := g.(interface{ Splitter() *futil.Splitter }); ok {
+		return s.Splitter()
+	}
+	return nil
+}
+
 // this is done here to give easy comparison with the above list.
 
 /*
@@ -65,8 +278,23 @@ this is done during the initialization of man in New().
 func (man *ManPso) loadPsoDescription() {
 
 	man.psod = map[string]string{
-		"gpso-0":  "single group with global best target; using setpso.NewGPso",
-		"clpso-0": "basic comprehensive learning each particle has its own group; using setpso.NewCLPso "}
+		"gpso-0":    "single group with global best target; using setpso.NewGPso",
+		"clpso-0":   "basic comprehensive learning each particle has its own group; using setpso.NewCLPso ",
+		"clpso-1":   "dimension-wise comprehensive learning with each bit learnt from one of 4 tournament winners; using setpso.NewCLPso1",
+		"lpso-0":    "local best with each particle targeting the best of itself and its ring neighbours; using setpso.NewLPso",
+		"lpso-1":    "local best using a Von Neumann grid neighbourhood; using setpso.NewLPso",
+		"lpso-2":    "local best using 3 random neighbours rewired every TryGapHeuristic iterations; using setpso.NewLPso",
+		"fipso-0":   "fully informed targeting all Von Neumann grid neighbours weighted by cost rank; using setpso.NewFIPso",
+		"islands-0": "4 concurrent islands of gpso-0, clpso-0, lpso-0 and fipso-0 in a ring with the best particle migrating every TryGapHeuristic updates and no item replacement; using setpso.NewIslands",
+		"npso-0":    "niching into species of particles within a tenth of the parameter bits of the species best, regrouped every TryGapHeuristic updates; using setpso.NewNPso",
+		"mopso-0":   "multi-objective with 4 groups led from a Pareto archive of 50 chosen by crowding distance; needs a multi-objective cost-function; using setpso.NewMOPso",
+		"bpso-0":    "baseline Kennedy-Eberhart sigmoid binary PSO with w = 1, c1 = c2 = 2 and vmax = 4; using setpso.NewBPso",
+		"ga-0":      "baseline steady state genetic algorithm with tournament selection, uniform crossover and bit mutation; using setpso.NewGA",
+		"sa-0":      "baseline simulated annealing with one chain for each particle cooling by 0.99 each update; using setpso.NewSA",
+		"ccpso-0":   "cooperative coevolution with a gpso-0 sub-swarm for each part of the cost-function's Splitter, or for 4 equal bit ranges, sharing the best parameters as context; using setpso.NewCCPso",
+		"pbil-0":    "population based incremental learning of a bit probability model from the best 2 samples with learning rate 0.1; using setpso.NewPBIL",
+		"apso-0":    "4 adaptive groups tuning phi and lfactor by the 1/5th success rule every 20 updates; using setpso.NewAPso",
+		"apso-1":    "4 adaptive groups choosing omega and lfactor settings by a UCB1 bandit every 20 updates; using setpso.NewAPso"}
 }
 
 /*
@@ -87,3 +315,38 @@ func (man *ManPso) PsoDescription() string {
 	}
 	return s
 }
+
+/*
+SelectCombiner selects by name the operation used by the SPSO to combine
+velocity probabilities. It returns an error if the name is not one of:
+
+	pseudo-add  p+q-pq (the default)
+	max         max(p,q)
+	capped-sum  min(1,p+q)
+	noisy-or    pseudo add with q inhibited with probability 0.5
+*/
+func (man *ManPso) SelectCombiner(name string) error {
+	if man.newCombiner(name) == nil {
+		return fmt.Errorf("the probability combiner %s could not be found", name)
+	}
+	man.combiner = name
+	return nil
+}
+
+// Combiner returns the name of the probability combiner in use.
+func (man *ManPso) Combiner() string { return man.combiner }
+
+// newCombiner returns the probability combiner by name or nil if not found.
+func (man *ManPso) newCombiner(name string) setpso.Combiner {
+	switch name {
+	case "pseudo-add":
+		return setpso.PseudoAdd{}
+	case "max":
+		return setpso.MaxProb{}
+	case "capped-sum":
+		return setpso.CappedSum{}
+	case "noisy-or":
+		return setpso.NoisyOr{Inhibit: 0.5}
+	}
+	return nil
+}
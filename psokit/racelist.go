@@ -0,0 +1,56 @@
+package psokit
+
+import (
+	"fmt"
+
+	"github.com/mathrgo/setpso"
+)
+
+/*
+SelectRacer selects by name the racing of noisy tries used by the SPSO in place
+of the list of tries. It returns an error if the name is not one of:
+
+	none       no racing (the default)
+	ocba       optimal computing budget allocation
+	hoeffding  Hoeffding race with delta 0.05 using the range of the samples
+	ttest      Welch t-test race at level 0.05
+
+Racing needs a cost-function that can be sampled, such as those built on
+futil.SFloatFunStub or futil.VFloatFunStub, otherwise the SPSO cannot be
+created; up to RaceBudget() extra cost samples are drawn each update. With none
+the list of tries is kept, as it is for deterministic cost-functions.
+*/
+func (man *ManPso) SelectRacer(name string) error {
+	if _, ok := man.newRacer(name); !ok {
+		return fmt.Errorf("the racer %s could not be found", name)
+	}
+	man.raceCase = name
+	return nil
+}
+
+// Racer returns the name of the racing in use.
+func (man *ManPso) Racer() string { return man.raceCase }
+
+// SetRaceBudget sets the number of extra cost samples drawn by racing each
+// update. The default is 40.
+func (man *ManPso) SetRaceBudget(n int) { man.raceBudget = n }
+
+// RaceBudget returns the number of extra cost samples drawn by racing each
+// update.
+func (man *ManPso) RaceBudget() int { return man.raceBudget }
+
+// newRacer returns the racer by name, which is nil for none, and false if not
+// found.
+func (man *ManPso) newRacer(name string) (setpso.Racer, bool) {
+	switch name {
+	case "none":
+		return nil, true
+	case "ocba":
+		return setpso.OCBA{}, true
+	case "hoeffding":
+		return &setpso.HoeffdingRace{Delta: 0.05}, true
+	case "ttest":
+		return &setpso.TTestRace{Alpha: 0.05}, true
+	}
+	return nil, false
+}
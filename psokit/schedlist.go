@@ -0,0 +1,155 @@
+package psokit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mathrgo/setpso"
+)
+
+/*
+CreateSchedule is the interface for creating heuristic schedules. v is the
+heuristic's value before scheduling and n is the number of updates in a run.
+*/
+type CreateSchedule interface {
+	Create(v float64, n int) setpso.Schedule
+}
+
+// heuristicIndex gives the heuristic index by name and whether it is an
+// integer heuristic.
+var heuristicIndex = map[string]struct {
+	isInt bool
+	index int
+}{
+	"phi":       {false, setpso.PhiHeuristic},
+	"omega":     {false, setpso.OmegaHeuristic},
+	"lfactor":   {false, setpso.LfactorHeuristic},
+	"loffset":   {false, setpso.LoffsetHeuristic},
+	"threshold": {false, setpso.ThresholdHeuristic},
+	"ntries":    {true, setpso.NTriesHeuristic},
+	"trygap":    {true, setpso.TryGapHeuristic},
+}
+
+/*
+SetSchedule sets the named heuristic to follow the named schedule during each
+run. The heuristic names are phi, omega, lfactor, loffset, threshold, ntries
+and trygap. The schedule name "none" removes the heuristic's schedule. It
+returns an error if either name is not found.
+*/
+func (man *ManPso) SetSchedule(heuristic, schedule string) error {
+	if _, ok := heuristicIndex[heuristic]; !ok {
+		return fmt.Errorf("the heuristic %s could not be found", heuristic)
+	}
+	if schedule == "none" {
+		delete(man.schedules, heuristic)
+		return nil
+	}
+	if man.schedd[schedule] == "" {
+		return fmt.Errorf("the schedule %s could not be found", schedule)
+	}
+	man.schedules[heuristic] = schedule
+	return nil
+}
+
+/*
+SetSchedules sets schedules from a comma separated list of heuristic=schedule
+pairs such as "omega=linear-half,lfactor=cosine-4".
+*/
+func (man *ManPso) SetSchedules(list string) error {
+	if list == "" {
+		return nil
+	}
+	for _, pair := range strings.Split(list, ",") {
+		hs := strings.SplitN(pair, "=", 2)
+		if len(hs) != 2 {
+			return fmt.Errorf("schedule %s is not of the form heuristic=schedule", pair)
+		}
+		if err := man.SetSchedule(hs[0], hs[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Schedules returns the schedules in use as a list in the form used by
+// SetSchedules().
+func (man *ManPso) Schedules() string {
+	list := make([]string, 0, len(man.schedules))
+	for h, s := range man.schedules {
+		list = append(list, h+"="+s)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+/*
+applySchedules attaches the selected schedules to the master heuristics of p0.
+This is done by CreatePso() before the SPSO is built on p0.
+*/
+func (man *ManPso) applySchedules(p0 *setpso.Pso) {
+	n := man.datalength * man.nthink
+	hu := p0.Heuristics()
+	for h, name := range man.schedules {
+		hi := heuristicIndex[h]
+		if hi.isInt {
+			p0.SetIntSchedule(hi.index, man.newSchedule(name, float64(hu.Int(hi.index)), n))
+		} else {
+			p0.SetFloatSchedule(hi.index, man.newSchedule(name, hu.Float(hi.index), n))
+		}
+	}
+}
+
+// newSchedule creates the named schedule for a heuristic with value v in a
+// run of n updates.
+func (man *ManPso) newSchedule(name string, v float64, n int) setpso.Schedule {
+	switch name {
+	case "linear-half":
+		return &setpso.LinearSchedule{From: v, To: v / 2, Iters: n}
+	case "exp-half":
+		return &setpso.ExpSchedule{From: v, To: v / 2, Tau: float64(n) / 4}
+	case "cosine-4":
+		return &setpso.CosineSchedule{Max: v, Min: v / 2, Period: n / 4}
+	case "step-3":
+		return &setpso.PiecewiseSchedule{
+			Iters:  []int{0, n / 3, 2 * n / 3},
+			Values: []float64{v, 0.75 * v, 0.5 * v}}
+	}
+	return man.addedSched[name].Create(v, n)
+}
+
+// loadScheduleDescription loads the description of the installed schedules.
+func (man *ManPso) loadScheduleDescription() {
+	man.schedd = map[string]string{
+		"linear-half": "linear from the heuristic's value to half of it over the run",
+		"exp-half":    "exponential decay towards half the value with time constant a quarter of the run",
+		"cosine-4":    "cosine from the value to half of it restarting 4 times in the run",
+		"step-3":      "steps down to 3/4 then 1/2 of the value at thirds of the run"}
+}
+
+/*
+AddSchedule adds a schedule creator s with an assigned name to reference it by
+where desc is the description of it. Note one cannot here reuse schedule names.
+*/
+func (man *ManPso) AddSchedule(name, desc string, s CreateSchedule) error {
+	if man.schedd[name] == "" {
+		man.schedd[name] = desc
+		man.addedSched[name] = s
+		return nil
+	}
+	return fmt.Errorf("attempted to add %s to a schedule that exists ", name)
+}
+
+// ScheduleDescription gives a description of the schedules by name.
+func (man *ManPso) ScheduleDescription() string {
+	keys := make([]string, 0, len(man.schedd))
+	for k := range man.schedd {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s := fmt.Sprintln("Schedule Description:")
+	for _, k := range keys {
+		s += fmt.Sprintf("%s :\n  %s\n", k, man.schedd[k])
+	}
+	return s
+}
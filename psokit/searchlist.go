@@ -0,0 +1,59 @@
+package psokit
+
+import (
+	"fmt"
+
+	"github.com/mathrgo/setpso"
+)
+
+/*
+SelectLocalSearch selects by name the local search applied to the best
+Personal-bests of the SPSO. It returns an error if the name is not one of:
+
+	none   no local search (the default)
+	flip   first-improvement single bit flip until no flip improves
+	2flip  a pass of best-improvement two bit flip over 1000 random pairs
+	vns    variable neighbourhood search with up to 3 flips for 20 iterations
+
+The search is made every LocalSearchPeriod() updates.
+*/
+func (man *ManPso) SelectLocalSearch(name string) error {
+	if _, ok := man.newLocalSearch(name); !ok {
+		return fmt.Errorf("the local search %s could not be found", name)
+	}
+	man.lsCase = name
+	return nil
+}
+
+// LocalSearch returns the name of the local search in use.
+func (man *ManPso) LocalSearch() string { return man.lsCase }
+
+/*
+SetLocalSearchPeriod sets the local search to be made every period updates on
+the topK best Personal-bests; topK = 1 searches just the global best. The
+default is every 10 updates on the global best.
+*/
+func (man *ManPso) SetLocalSearchPeriod(period, topK int) {
+	man.lsPeriod = period
+	man.lsTopK = topK
+}
+
+// LocalSearchPeriod returns the number of updates between local searches and
+// the number of Personal-bests searched.
+func (man *ManPso) LocalSearchPeriod() (period, topK int) { return man.lsPeriod, man.lsTopK }
+
+// newLocalSearch returns the local search by name, which is nil for none, and
+// false if not found.
+func (man *ManPso) newLocalSearch(name string) (setpso.LocalSearcher, bool) {
+	switch name {
+	case "none":
+		return nil, true
+	case "flip":
+		return &setpso.BitFlipSearch{}, true
+	case "2flip":
+		return &setpso.TwoFlipSearch{MaxPasses: 1, Sample: 1000}, true
+	case "vns":
+		return &setpso.VNSearch{KMax: 3, Iterations: 20}, true
+	}
+	return nil, false
+}
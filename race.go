@@ -0,0 +1,380 @@
+package setpso
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+/*
+Sampler is implemented by cost-functions with noisy costs, such as
+futil.SFloatFunStub, so that SetRacing() can re-evaluate tries.
+*/
+type Sampler interface {
+	// Sample draws a new cost of t, adds it to the cost of t as UpdateCost()
+	// does and returns it. When restart is true the cost of t is started
+	// afresh from the new cost instead, which racing does for the first
+	// sample of a try so that its cost only holds its own samples.
+	Sample(t Try, restart bool) float64
+}
+
+// canSample returns true if f, or the cost-function it wraps, is a Sampler.
+func canSample(f Fun) bool {
+	for {
+		switch g := f.(type) {
+		case *CountingFun:
+			f = g.Fun
+		default:
+			_, ok := f.(Sampler)
+			return ok
+		}
+	}
+}
+
+// SampleStats are the running statistics of the cost samples of a try.
+type SampleStats struct {
+	N        int
+	Mean, M2 float64
+	Min, Max float64
+}
+
+// Add adds the sample x using Welford's method.
+func (s *SampleStats) Add(x float64) {
+	if s.N == 0 || x < s.Min {
+		s.Min = x
+	}
+	if s.N == 0 || x > s.Max {
+		s.Max = x
+	}
+	s.N++
+	d := x - s.Mean
+	s.Mean += d / float64(s.N)
+	s.M2 += d * (x - s.Mean)
+}
+
+// Variance returns the unbiased sample variance, which is 0 for fewer than 2
+// samples.
+func (s *SampleStats) Variance() float64 {
+	if s.N < 2 {
+		return 0
+	}
+	return s.M2 / float64(s.N-1)
+}
+
+// seMean returns the squared standard error of the mean.
+func (s *SampleStats) seMean() float64 {
+	if s.N == 0 {
+		return 0
+	}
+	return s.Variance() / float64(s.N)
+}
+
+/*
+Comparison is the race between the candidate current try of a particle and its
+Personal-best, which is only replaced if the candidate is shown to be better.
+*/
+type Comparison struct {
+	Particle   int
+	Best, Cand SampleStats
+}
+
+// diff returns the difference of the mean costs, which is positive when the
+// candidate is better, and its standard error.
+func (c *Comparison) diff() (d, se float64) {
+	return c.Best.Mean - c.Cand.Mean, math.Sqrt(c.Best.seMean() + c.Cand.seMean())
+}
+
+// fewest returns the comparison with a side of fewer than n samples that
+// has fewest samples, and whether that side is the candidate; i < 0 if none.
+func fewest(cs []Comparison, n int) (i int, cand bool) {
+	i = -1
+	for k := range cs {
+		c := &cs[k]
+		if c.Best.N < n {
+			i, cand, n = k, false, c.Best.N
+		}
+		if c.Cand.N < n {
+			i, cand, n = k, true, c.Cand.N
+		}
+	}
+	return i, cand
+}
+
+// undecided returns the comparison in cs for which open is true with fewest
+// samples, after any side with fewer than 2 samples, and whether to sample its
+// side with fewer samples, which is the candidate; i < 0 if none is open.
+func undecided(cs []Comparison, open func(c *Comparison) bool) (i int, cand bool) {
+	if i, cand = fewest(cs, 2); i >= 0 {
+		return i, cand
+	}
+	n := 0
+	for k := range cs {
+		c := &cs[k]
+		if m := c.Best.N + c.Cand.N; open(c) && (i < 0 || m < n) {
+			i, n = k, m
+		}
+	}
+	if i < 0 {
+		return -1, false
+	}
+	return i, cs[i].Cand.N <= cs[i].Best.N
+}
+
+/*
+Racer is the interface to a strategy used by SetRacing() to share out the
+re-evaluations of an update between the Comparisons of the particles and then
+decide them.
+*/
+type Racer interface {
+	// Next returns the comparison in cs to sample next and whether to sample
+	// its candidate rather than its Personal-best; i < 0 when no comparison
+	// needs more samples.
+	Next(cs []Comparison) (i int, cand bool)
+	// Better returns true if the candidate of c is taken to be better than
+	// the Personal-best.
+	Better(c *Comparison) bool
+	// About gives a description of the strategy.
+	About() string
+}
+
+/*
+OCBA is optimal computing budget allocation for the pairwise Comparisons. Each
+side is sampled twice and then each sample goes to the comparison with the
+greatest normal approximation to the probability that the order of its means
+is wrong, on the side that keeps the numbers of samples in proportion to their
+standard deviations. It uses the whole budget and the candidate is better if
+both sides have been sampled and its mean cost is less.
+*/
+type OCBA struct{}
+
+// Next chooses the most uncertain comparison.
+func (r OCBA) Next(cs []Comparison) (int, bool) {
+	if i, cand := fewest(cs, 2); i >= 0 {
+		return i, cand
+	}
+	best, pics := -1, 0.0
+	for k := range cs {
+		d, se := cs[k].diff()
+		if se == 0 {
+			continue
+		}
+		if p := 0.5 * math.Erfc(math.Abs(d)/se/math.Sqrt2); p > pics {
+			best, pics = k, p
+		}
+	}
+	if best < 0 {
+		return -1, false
+	}
+	c := &cs[best]
+	sb := math.Sqrt(c.Best.Variance())
+	sc := math.Sqrt(c.Cand.Variance())
+	return best, float64(c.Cand.N)*sb < float64(c.Best.N)*sc
+}
+
+// Better returns true if both sides have samples and the candidate has the
+// lower mean cost.
+func (r OCBA) Better(c *Comparison) bool {
+	return c.Cand.N > 0 && c.Best.N > 0 && c.Cand.Mean < c.Best.Mean
+}
+
+// About gives a description of the strategy.
+func (r OCBA) About() string { return "optimal computing budget allocation" }
+
+/*
+HoeffdingRace samples each Comparison until the Hoeffding confidence intervals
+of its means, which hold with probability 1-Delta, separate. Range is the range
+of the costs; when Range <= 0 the range of the samples of the comparison is
+used, which makes the race slow to decide. Each sample goes to the side with
+fewer samples of the undecided comparison with fewest samples. The candidate is
+only better if the race is decided in its favour.
+*/
+type HoeffdingRace struct {
+	Delta, Range float64
+}
+
+// bounds returns the half widths of the confidence intervals of c.
+func (r *HoeffdingRace) bounds(c *Comparison) (hb, hc float64) {
+	rg := r.Range
+	if rg <= 0 {
+		rg = math.Max(c.Best.Max, c.Cand.Max) - math.Min(c.Best.Min, c.Cand.Min)
+	}
+	l := math.Log(2 / r.Delta)
+	return rg * math.Sqrt(l/(2*float64(c.Best.N))), rg * math.Sqrt(l/(2*float64(c.Cand.N)))
+}
+
+// overlap returns how much the confidence intervals of c overlap, which is
+// negative once they separate.
+func (r *HoeffdingRace) overlap(c *Comparison) float64 {
+	d, _ := c.diff()
+	hb, hc := r.bounds(c)
+	return hb + hc - math.Abs(d)
+}
+
+// Next chooses the undecided comparison with fewest samples.
+func (r *HoeffdingRace) Next(cs []Comparison) (int, bool) {
+	return undecided(cs, func(c *Comparison) bool { return r.overlap(c) >= 0 })
+}
+
+// Better returns true if the race is decided for the candidate.
+func (r *HoeffdingRace) Better(c *Comparison) bool {
+	d, _ := c.diff()
+	return c.Best.N >= 2 && c.Cand.N >= 2 && d > 0 && r.overlap(c) < 0
+}
+
+// About gives a description of the strategy.
+func (r *HoeffdingRace) About() string {
+	return fmt.Sprintf("Hoeffding race with delta %g and range %g", r.Delta, r.Range)
+}
+
+/*
+TTestRace samples each Comparison until Welch's t-test at significance level
+Alpha tells its means apart. The critical value for the Welch-Satterthwaite
+degrees of freedom is given by futil.TQuantile().
+Each sample goes to the side with fewer samples of the undecided comparison with
+fewest samples. Since the test is repeated as samples are added the chance of
+a wrong decision is somewhat more than Alpha. The candidate is only better if
+the test is decided in its favour.
+*/
+type TTestRace struct {
+	Alpha float64
+}
+
+// score returns the t statistic of c divided by its critical value, whose
+// magnitude exceeds 1 once the test is decided.
+func (r *TTestRace) score(c *Comparison) float64 {
+	d, se := c.diff()
+	if se == 0 {
+		// identical samples decide the test
+		if d > 0 {
+			return math.Inf(1)
+		}
+		return math.Inf(-1)
+	}
+	vb, vc := c.Best.seMean(), c.Cand.seMean()
+	df := (vb + vc) * (vb + vc) / (vb*vb/float64(c.Best.N-1) + vc*vc/float64(c.Cand.N-1))
+	return d / se / futil.TQuantile(1-r.Alpha/2, df)
+}
+
+// Next chooses the undecided comparison with fewest samples.
+func (r *TTestRace) Next(cs []Comparison) (int, bool) {
+	return undecided(cs, func(c *Comparison) bool { return math.Abs(r.score(c)) <= 1 })
+}
+
+// Better returns true if the test is decided for the candidate.
+func (r *TTestRace) Better(c *Comparison) bool {
+	return c.Best.N >= 2 && c.Cand.N >= 2 && r.score(c) > 1
+}
+
+// About gives a description of the strategy.
+func (r *TTestRace) About() string { return fmt.Sprintf("Welch t-test race at level %g", r.Alpha) }
+
+// racing is the state of the racing of a swarm.
+type racing struct {
+	racer  Racer
+	budget int
+	// true for particles with a new candidate in this update
+	valid []bool
+	// statistics of the Personal-best of each particle and the Parameters
+	// they are for
+	best   []SampleStats
+	bestX  []*big.Int
+	cs     []Comparison
+	evals  int64
+	nwins  int
+	sample Sampler
+}
+
+/*
+SetRacing turns on racing of noisy tries, which replaces the success counting
+of the list of tries in SetParams(); a nil racer turns this off. Each update the
+candidate current try of every particle is compared with its Personal-best and
+up to budget extra cost samples are shared out between the comparisons by the
+racer, after which the Personal-best is replaced when the racer decides the
+candidate is better. The samples of a Personal-best are kept while it stays the
+Personal-best. It returns an error if the cost-function is not a Sampler.
+
+Racing is off by default, even for Samplers, so the success counting of the
+list of tries is kept on purpose: it needs no extra cost evaluations and
+leaves runs without racing as they were.
+*/
+func (pso *Pso) SetRacing(racer Racer, budget int) error {
+	if racer == nil {
+		pso.racing = nil
+		return nil
+	}
+	if !canSample(pso.fun) {
+		return fmt.Errorf("cost-function %T cannot be sampled", pso.fun)
+	}
+	r := &racing{racer: racer, budget: budget, sample: pso.fun.(Sampler)}
+	r.valid = make([]bool, len(pso.Pt))
+	r.best = make([]SampleStats, len(pso.Pt))
+	r.bestX = make([]*big.Int, len(pso.Pt))
+	for i := range r.bestX {
+		r.bestX[i] = new(big.Int)
+	}
+	pso.racing = r
+	return nil
+}
+
+// RaceEvaluations returns the number of cost samples drawn by racing.
+func (pso *Pso) RaceEvaluations() int64 {
+	if pso.racing == nil {
+		return 0
+	}
+	return pso.racing.evals
+}
+
+// RaceWins returns the number of Personal-bests replaced by racing.
+func (pso *Pso) RaceWins() int {
+	if pso.racing == nil {
+		return 0
+	}
+	return pso.racing.nwins
+}
+
+// race runs the comparisons of the particles with a new candidate. It is
+// called after SetParams() has been called for every particle.
+func (pso *Pso) race() {
+	r := pso.racing
+	r.cs = r.cs[:0]
+	for i := range pso.Pt {
+		if !r.valid[i] {
+			continue
+		}
+		if x := pso.Pt[i].bestTry.Parameter(); x.Cmp(r.bestX[i]) != 0 {
+			// the Personal-best has been replaced other than by racing
+			r.best[i] = SampleStats{}
+			r.bestX[i].Set(x)
+		}
+		r.cs = append(r.cs, Comparison{Particle: i, Best: r.best[i]})
+	}
+	for n := 0; n < r.budget; n++ {
+		k, cand := r.racer.Next(r.cs)
+		if k < 0 {
+			break
+		}
+		c := &r.cs[k]
+		p := &pso.Pt[c.Particle]
+		if cand {
+			c.Cand.Add(r.sample.Sample(p.current, c.Cand.N == 0))
+		} else {
+			c.Best.Add(r.sample.Sample(p.bestTry, c.Best.N == 0))
+		}
+		r.evals++
+	}
+	for k := range r.cs {
+		c := &r.cs[k]
+		p := &pso.Pt[c.Particle]
+		r.best[c.Particle] = c.Best
+		if r.racer.Better(c) {
+			pso.fun.Copy(p.bestTry, p.current)
+			p.tries = p.tries[:0]
+			p.improved = true
+			r.best[c.Particle] = c.Cand
+			r.bestX[c.Particle].Set(p.bestTry.Parameter())
+			r.nwins++
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package setpso_test
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/futil"
+	"github.com/mathrgo/setpso/fun/multimode"
+)
+
+func TestRacing(t *testing.T) {
+	var s setpso.SampleStats
+	for _, x := range []float64{1, 2, 3, 4} {
+		s.Add(x)
+	}
+	if s.N != 4 || s.Mean != 2.5 || math.Abs(s.Variance()-5.0/3.0) > 1e-12 || s.Min != 1 || s.Max != 4 {
+		t.Errorf("statistics of 1, 2, 3, 4 are %+v with variance %f", s, s.Variance())
+	}
+
+	racers := []setpso.Racer{
+		setpso.OCBA{},
+		&setpso.HoeffdingRace{Delta: 0.05, Range: 1},
+		&setpso.TTestRace{Alpha: 0.05},
+	}
+	rnd := rand.New(rand.NewSource(99))
+	for _, r := range racers {
+		// the first candidate is clearly better and the second costs the same
+		// as its Personal-best without noise
+		cs := make([]setpso.Comparison, 2)
+		for n := 0; n < 100; n++ {
+			k, cand := r.Next(cs)
+			switch {
+			case k < 0:
+				n = 100
+			case k == 1 && cand:
+				cs[k].Cand.Add(1)
+			case k == 1:
+				cs[k].Best.Add(1)
+			case cand:
+				cs[k].Cand.Add(0.1 * rnd.NormFloat64())
+			default:
+				cs[k].Best.Add(1 + 0.1*rnd.NormFloat64())
+			}
+		}
+		if !r.Better(&cs[0]) {
+			t.Errorf("%s did not pick the better candidate from %+v", r.About(), cs[0])
+		}
+		if r.Better(&cs[1]) {
+			t.Errorf("%s picked the same candidate from %+v", r.About(), cs[1])
+		}
+	}
+
+	// cost-functions that cannot be sampled are refused
+	if err := newPso(10).SetRacing(setpso.OCBA{}, 10); err == nil {
+		t.Errorf("racing was set up for a deterministic cost-function")
+	}
+
+	newRun := func(r setpso.Racer, sd int64) (*setpso.GPso, *setpso.EvalCounter) {
+		c := new(setpso.EvalCounter)
+		f := setpso.NewCountingFun(multimode.NewFun(4, 16, 0.1, 0.2, 100, 2, 3142), c)
+		p0 := setpso.NewPso(10, f, sd)
+		if err := p0.SetRacing(r, 30); err != nil {
+			t.Fatal(err)
+		}
+		return setpso.NewGPso(p0), c
+	}
+	for _, r := range racers {
+		pso, c := newRun(r, 578)
+		for i := 0; i < 100; i++ {
+			pso.Update()
+		}
+		// the samples keep to the budget and are counted as cost updates
+		n := pso.RaceEvaluations()
+		_, updateCost, _ := c.Counts()
+		if n == 0 || n > 30*100 || updateCost < n {
+			t.Errorf("%s drew %d samples with %d cost updates", r.About(), n, updateCost)
+		}
+		if pso.RaceWins() == 0 {
+			t.Errorf("%s replaced no Personal-bests", r.About())
+		}
+	}
+
+	// with a budget smaller than the number of particles only the sampled
+	// candidates can win, so there are no more wins than samples
+	p0 := setpso.NewPso(20, multimode.NewFun(4, 16, 0.1, 0.2, 100, 2, 3142), 578)
+	if err := p0.SetRacing(setpso.OCBA{}, 5); err != nil {
+		t.Fatal(err)
+	}
+	small := setpso.NewGPso(p0)
+	for i := 0; i < 50; i++ {
+		wins := small.RaceWins()
+		small.Update()
+		if n := small.RaceWins() - wins; n > 5 {
+			t.Errorf("update %d had %d wins from 5 samples", i, n)
+		}
+	}
+	if (setpso.OCBA{}).Better(&setpso.Comparison{Best: setpso.SampleStats{N: 3, Mean: 1}}) {
+		t.Errorf("an unsampled candidate was better")
+	}
+
+	// checkpoints keep the racing state
+	pso, _ := newRun(setpso.OCBA{}, 578)
+	for i := 0; i < 20; i++ {
+		pso.Update()
+	}
+	var buf bytes.Buffer
+	if err := pso.Checkpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	q, _ := newRun(setpso.OCBA{}, 99)
+	if err := q.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if q.RaceEvaluations() != pso.RaceEvaluations() || q.RaceWins() != pso.RaceWins() {
+		t.Errorf("restored racing has %d samples and %d wins not %d and %d",
+			q.RaceEvaluations(), q.RaceWins(), pso.RaceEvaluations(), pso.RaceWins())
+	}
+}
+
+func TestVFloatFun(t *testing.T) {
+	// the Welch test gives full confidence for deterministic costs
+	f := multimode.NewVFun(4, 16, 0.1, 0.0, 100, 2, 3142)
+	x, y := f.NewTry(), f.NewTry()
+	f.SetTry(x, big.NewInt(6500))
+	f.SetTry(y, big.NewInt(30000))
+	// SetTry takes one sample so the variances are not known yet
+	if r := f.Cmp(x, y, futil.CostMode); r != -0.5 {
+		t.Errorf("comparison of single samples gave %f", r)
+	}
+	f.UpdateCost(x)
+	f.UpdateCost(y)
+	if r := f.Cmp(x, y, futil.CostMode); r != -1 {
+		t.Errorf("comparison of deterministic costs gave %f", r)
+	}
+	if _, err := setpso.NewCachingFun(f, 50); err == nil {
+		t.Errorf("noisy cost-function was cached")
+	}
+
+	// a noisy run finds the global minimum at x = 0.099
+	for _, racer := range []setpso.Racer{nil, setpso.OCBA{}} {
+		p0 := setpso.NewPso(10, multimode.NewVFun(4, 16, 0.1, 0.2, 100, 2, 3142), 578)
+		if err := p0.SetRacing(racer, 30); err != nil {
+			t.Fatal(err)
+		}
+		pso := setpso.NewGPso(p0)
+		for i := 0; i < 300; i++ {
+			pso.Update()
+		}
+		f.SetTry(x, pso.Part(pso.BestParticle()).BestTry().Parameter())
+		if x.Fbits() > 0.05 {
+			t.Errorf("racing %v found %s with cost %s", racer, x.Decode(), x.Cost())
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package setpso
+
+/*
+itemUsage keeps track of how often each set item is used by the Personal-bests
+over a sliding window of updates for item replacement.
+*/
+type itemUsage struct {
+	// window length in updates
+	window int
+	// usage fraction below which an item is replaced
+	threshold float64
+	// ring of the number of Personal-bests using each item at each update
+	// in the window
+	ring [][]int
+	// next position in ring
+	pos int
+	// number of Personal-bests using each item summed over the window
+	sum []int
+	// number of updates since each item's usage history started
+	age []int
+	// number of items replaced so far
+	nreplaced int
+}
+
+/*
+SetItemReplacement turns on the item replacement protocol of Fun.Delete(). The
+usage of each item is measured as the fraction of the Personal-bests it is in
+over the last window updates. When an item's usage falls below threshold
+Delete() is called for it and if this returns true the item has a new meaning
+so the velocity component of each particle for the item is set to zero, the
+tries holding the item are dropped from the lists of tries and the current and
+Personal-best tries are re-evaluated. The usage history of a replaced item
+starts again so it is given window updates to prove itself. window = 0 turns
+item replacement off.
+
+When costs are evaluated by workers Delete() is also called on each worker's
+instance of the cost function, so replacements must be the same for instances
+created in the same way. Item meanings are not stored by Checkpoint(). Item
+replacement is turned off for the islands of Islands.
+*/
+func (pso *Pso) SetItemReplacement(window int, threshold float64) {
+	if window <= 0 {
+		pso.usage = nil
+		return
+	}
+	u := &itemUsage{window: window, threshold: threshold}
+	u.ring = make([][]int, window)
+	for k := range u.ring {
+		u.ring[k] = make([]int, pso.maxLen)
+	}
+	u.sum = make([]int, pso.maxLen)
+	u.age = make([]int, pso.maxLen)
+	pso.usage = u
+}
+
+// ItemsReplaced returns the number of items replaced by the cost function
+// since item replacement was turned on.
+func (pso *Pso) ItemsReplaced() int {
+	if pso.usage == nil {
+		return 0
+	}
+	return pso.usage.nreplaced
+}
+
+// ItemUsage returns the usage fraction of the ith item over the updates in
+// its usage history or 1.0 if item replacement is off.
+func (pso *Pso) ItemUsage(i int) float64 {
+	u := pso.usage
+	if u == nil || u.age[i] == 0 {
+		return 1.0
+	}
+	n := u.age[i]
+	if n > u.window {
+		n = u.window
+	}
+	return float64(u.sum[i]) / float64(n*len(pso.Pt))
+}
+
+// replaceItems updates the item usage and replaces the items whose usage has
+// fallen below the threshold. It is called by PUpdate().
+func (pso *Pso) replaceItems() {
+	u := pso.usage
+	counts := u.ring[u.pos]
+	for j := range counts {
+		u.sum[j] -= counts[j]
+		counts[j] = 0
+	}
+	for i := range pso.Pt {
+		ForEachBit(pso.Pt[i].bestTry.Parameter(), func(j int) {
+			if j < len(counts) {
+				counts[j]++
+			}
+		})
+	}
+	u.pos = (u.pos + 1) % u.window
+	replaced := false
+	for j := range counts {
+		u.sum[j] += counts[j]
+		u.age[j]++
+		if u.age[j] < u.window || pso.ItemUsage(j) >= u.threshold {
+			continue
+		}
+		if !pso.fun.Delete(j) {
+			continue
+		}
+		for _, f := range pso.workerFun {
+			f.Delete(j)
+		}
+		u.nreplaced++
+		replaced = true
+		for k := range u.ring {
+			u.ring[k][j] = 0
+		}
+		u.sum[j] = 0
+		u.age[j] = 0
+		for i := range pso.Pt {
+			p := &pso.Pt[i]
+			p.zeroVel(j)
+			p.dropTries(j)
+		}
+	}
+	if !replaced {
+		return
+	}
+	// re-evaluate tries whose meaning may have changed
+	for i := range pso.Pt {
+		p := &pso.Pt[i]
+		pso.temp.Set(p.current.Parameter())
+		pso.fun.SetTry(p.current, pso.temp)
+		pso.temp.Set(p.bestTry.Parameter())
+		pso.fun.SetTry(p.bestTry, pso.temp)
+	}
+}
+
+// dropTries removes the tries holding item j from the list of tries since
+// their costs no longer hold once the item is replaced.
+func (p *Particle) dropTries(j int) {
+	k := 0
+	for _, t := range p.tries {
+		if t.Parameter().Bit(j) == 0 {
+			p.tries[k] = t
+			k++
+		}
+	}
+	p.tries = p.tries[:k]
+}
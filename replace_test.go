@@ -0,0 +1,50 @@
+package setpso_test
+
+import (
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/futil"
+	"github.com/mathrgo/setpso/fun/poolsum"
+)
+
+func TestItemReplacement(t *testing.T) {
+	f := poolsum.New(30, 90, 20, 3142)
+	pso := setpso.NewGPso(setpso.NewPso(10, f, 578))
+	pso.SetItemReplacement(20, 0.05)
+	for i := 0; i < 200; i++ {
+		pso.Update()
+	}
+	n := pso.ItemsReplaced()
+	if n == 0 {
+		t.Fatalf("no items were replaced")
+	}
+	if r := f.IntFun.(*poolsum.Fun).Replaced(); r != n {
+		t.Errorf("cost function replaced %d items but the swarm counted %d", r, n)
+	}
+	// islands turn item replacement off since migrants would change meaning
+	g := poolsum.New(30, 90, 20, 3142)
+	p0 := setpso.NewPso(10, g, 578)
+	p0.SetItemReplacement(20, 0.05)
+	is := setpso.NewIslands(&setpso.RingTopology{K: 1}, 5, 1, 99, setpso.NewGPso(p0))
+	for i := 0; i < 200; i++ {
+		is.Update()
+	}
+	if r := g.IntFun.(*poolsum.Fun).Replaced(); r != 0 {
+		t.Errorf("island replaced %d items", r)
+	}
+	// the tries must have the costs of the replaced items; equal costs compare
+	// the same both ways
+	try := f.NewTry()
+	for i := 0; i < pso.Nparticles(); i++ {
+		f.SetTry(try, pso.LocalBestTry(i).Parameter())
+		if f.Cmp(try, pso.LocalBestTry(i), futil.CostMode) != f.Cmp(pso.LocalBestTry(i), try, futil.CostMode) {
+			t.Errorf("particle %d has a stale Personal-best cost", i)
+		}
+	}
+	for j := 0; j < f.MaxLen(); j++ {
+		if u := pso.ItemUsage(j); u < 0.0 || u > 1.0 {
+			t.Errorf("item %d has usage %f", j, u)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package setpso
+
+import (
+	"math/big"
+
+	"github.com/mathrgo/setpso/fun/futil"
+)
+
+/*
+RestartPolicy configures the partial restart of a stagnant swarm used by
+SetRestart(). The swarm is stagnant when the global best has not improved for
+MaxAge updates and the mean pairwise Hamming distance between the
+Personal-best Parameters, given by Diversity(), is at most MinDiversity; a
+MinDiversity <= 0 leaves out the distance test. On stagnation the best Elite
+particles are kept and Fraction of all the particles, taken at random from the
+rest, are given new random Parameters that satisfy the constraints. Up to
+ArchiveSize of the best distinct Personal-bests seen at restarts are kept in an
+archive, which always holds the best try found so far. The archive is kept in
+the same way as the elite archive of SetEliteArchive() with any two
+Parameters differing.
+*/
+type RestartPolicy struct {
+	MaxAge       int
+	MinDiversity float64
+	Fraction     float64
+	Elite        int
+	ArchiveSize  int
+}
+
+// restartState is the state of the restart policy of a swarm.
+type restartState struct {
+	RestartPolicy
+	// updates since the global best improved or the last restart
+	age int
+	// best try found so far
+	best Try
+	// best tries seen at restarts
+	archive *eliteArchive
+	// number of restarts so far
+	nrestarts int
+}
+
+// restartBudget is the number of attempts made to find a random try that
+// satisfies the constraints when restarting a particle.
+const restartBudget = 1000
+
+/*
+SetRestart turns on the partial restart of the swarm when it is stagnant using
+the given policy, which is copied; nil turns this off. The check is made at the
+end of each PUpdate(). Particles that cannot be given new Parameters that
+satisfy the constraints after 1000 attempts are left as they are.
+*/
+func (pso *Pso) SetRestart(policy *RestartPolicy) {
+	if policy == nil {
+		pso.restart = nil
+		return
+	}
+	r := &restartState{RestartPolicy: *policy}
+	if r.ArchiveSize < 1 {
+		r.ArchiveSize = 1
+	}
+	r.archive = newEliteArchive(r.ArchiveSize, 0)
+	r.best = pso.fun.NewTry()
+	pso.fun.Copy(r.best, pso.Pt[pso.bestParticle].bestTry)
+	pso.restart = r
+}
+
+// Restarts returns the number of restarts so far.
+func (pso *Pso) Restarts() int {
+	if pso.restart == nil {
+		return 0
+	}
+	return pso.restart.nrestarts
+}
+
+/*
+Archive returns the archive of the best tries seen at restarts together with
+the best try found so far in order of increasing cost. It is empty when
+restarts are off. The costs of archived tries are not re-evaluated.
+*/
+func (pso *Pso) Archive() []Try {
+	r := pso.restart
+	if r == nil {
+		return nil
+	}
+	tries := r.archive.tries
+	a := make([]Try, 0, len(tries)+1)
+	if len(tries) == 0 || pso.fun.Cmp(r.best, tries[0], futil.CostMode) < 0.0 {
+		a = append(a, r.best)
+	}
+	return append(a, tries...)
+}
+
+// Diversity returns the mean Hamming distance between the Personal-best
+// Parameters of each pair of particles.
+func (pso *Pso) Diversity() float64 {
+	n := len(pso.Pt)
+	if n < 2 {
+		return 0.0
+	}
+	diff := new(big.Int)
+	sum := 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			diff.Xor(pso.Pt[i].bestTry.Parameter(), pso.Pt[j].bestTry.Parameter())
+			sum += CardinalSize(diff)
+		}
+	}
+	return float64(sum) / float64(n*(n-1)/2)
+}
+
+// checkRestart updates the stagnation age and restarts part of the swarm when
+// it is stagnant. It is called by PUpdate() after UpdateGlobal().
+func (pso *Pso) checkRestart() {
+	r := pso.restart
+	r.age++
+	gbest := pso.Pt[pso.bestParticle].bestTry
+	if pso.fun.Cmp(gbest, r.best, futil.CostMode) < 0.0 {
+		pso.fun.Copy(r.best, gbest)
+		r.age = 0
+	}
+	if r.age < r.MaxAge || (r.MinDiversity > 0 && pso.Diversity() > r.MinDiversity) {
+		return
+	}
+	r.age = 0
+	r.nrestarts++
+	order := bestFirst(pso)
+	elite := r.Elite
+	if elite > len(order) {
+		elite = len(order)
+	}
+	for _, i := range order[:elite] {
+		r.archive.add(pso.fun, pso.Pt[i].bestTry)
+	}
+	rest := order[elite:]
+	nr := int(r.Fraction*float64(len(pso.Pt)) + 0.5)
+	if nr > len(rest) {
+		nr = len(rest)
+	}
+	// choose the particles to restart at random from the rest
+	pso.rnd.Shuffle(len(rest), func(a, b int) { rest[a], rest[b] = rest[b], rest[a] })
+	t := pso.fun.NewTry()
+	for _, i := range rest[:nr] {
+		if pso.sample(t, pso.Pt[i].hint, pso.rnd, restartBudget) {
+			pso.SetParticle(i, t.Parameter())
+		}
+	}
+	pso.UpdateGlobal()
+}
@@ -0,0 +1,63 @@
+package setpso_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/futil"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+func TestRestart(t *testing.T) {
+	policy := &setpso.RestartPolicy{MaxAge: 20, MinDiversity: 30, Fraction: 0.5, Elite: 2, ArchiveSize: 5}
+	newRun := func(sd int64) *setpso.GPso {
+		pso := setpso.NewGPso(setpso.NewPso(10, subsetsum.New(100, 20, 3142), sd))
+		pso.SetRestart(policy)
+		return pso
+	}
+	pso := newRun(578)
+	f := subsetsum.New(100, 20, 3142)
+	best := f.NewTry()
+	f.Copy(best, pso.LocalBestTry(pso.BestParticle()))
+	for i := 0; i < 500; i++ {
+		pso.Update()
+		if gb := pso.LocalBestTry(pso.BestParticle()); f.Cmp(gb, best, futil.CostMode) < 0.0 {
+			f.Copy(best, gb)
+		}
+	}
+	if pso.Restarts() == 0 {
+		t.Fatalf("no restarts with diversity %f", pso.Diversity())
+	}
+	a := pso.Archive()
+	if len(a) == 0 || len(a) > 6 {
+		t.Fatalf("archive has %d tries", len(a))
+	}
+	// the best-ever try is never lost
+	if f.Cmp(best, a[0], futil.CostMode) < 0.0 {
+		t.Errorf("archive best %s is worse than best found %s", a[0].Cost(), best.Cost())
+	}
+	for k := 1; k < len(a); k++ {
+		if f.Cmp(a[k], a[k-1], futil.CostMode) < 0.0 {
+			t.Errorf("archive is not in order of cost at %d", k)
+		}
+	}
+
+	// checkpoints keep the restart state
+	var buf bytes.Buffer
+	if err := pso.Checkpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	q := newRun(99)
+	if err := q.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		pso.Update()
+		q.Update()
+	}
+	samePersonalBests(t, pso, q)
+	if pso.Restarts() != q.Restarts() {
+		t.Errorf("restored swarm made %d restarts not %d", q.Restarts(), pso.Restarts())
+	}
+}
@@ -0,0 +1,205 @@
+package setpso
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+/*
+Schedule gives the value of a heuristic as a function of the number of updates
+iter done so far, starting at 0. Schedules are attached to the master
+heuristics of a Pso with SetFloatSchedule() or SetIntSchedule().
+*/
+type Schedule interface {
+	Value(iter int) float64
+	// About gives a description of the schedule.
+	About() string
+}
+
+// LinearSchedule moves linearly from From to To over Iters updates and then
+// stays at To.
+type LinearSchedule struct {
+	From, To float64
+	Iters    int
+}
+
+// Value returns the linearly interpolated value at iter.
+func (s *LinearSchedule) Value(iter int) float64 {
+	if iter >= s.Iters {
+		return s.To
+	}
+	return s.From + (s.To-s.From)*float64(iter)/float64(s.Iters)
+}
+
+// About gives a description of the linear schedule.
+func (s *LinearSchedule) About() string {
+	return fmt.Sprintf("linear from %g to %g over %d updates", s.From, s.To, s.Iters)
+}
+
+// ExpSchedule decays exponentially from From towards To with time constant
+// Tau updates. A Tau that is not positive gives To straight away.
+type ExpSchedule struct {
+	From, To, Tau float64
+}
+
+// Value returns To+(From-To)exp(-iter/Tau).
+func (s *ExpSchedule) Value(iter int) float64 {
+	if s.Tau <= 0 {
+		return s.To
+	}
+	return s.To + (s.From-s.To)*math.Exp(-float64(iter)/s.Tau)
+}
+
+// About gives a description of the exponential schedule.
+func (s *ExpSchedule) About() string {
+	return fmt.Sprintf("exponential from %g towards %g with time constant %g", s.From, s.To, s.Tau)
+}
+
+/*
+CosineSchedule follows half a cosine from Max down to Min over Period updates
+and then restarts at Max. After each restart the period is multiplied by Mult
+when Mult > 1.
+*/
+type CosineSchedule struct {
+	Max, Min float64
+	Period   int
+	Mult     float64
+}
+
+// Value returns the cosine annealed value at iter.
+func (s *CosineSchedule) Value(iter int) float64 {
+	p := s.Period
+	if p <= 0 {
+		return s.Max
+	}
+	t := iter
+	for t >= p {
+		t -= p
+		if s.Mult > 1.0 {
+			p = int(float64(p) * s.Mult)
+		}
+	}
+	return s.Min + 0.5*(s.Max-s.Min)*(1.0+math.Cos(math.Pi*float64(t)/float64(p)))
+}
+
+// About gives a description of the cosine schedule.
+func (s *CosineSchedule) About() string {
+	return fmt.Sprintf("cosine from %g to %g restarting every %d updates times %g",
+		s.Max, s.Min, s.Period, s.Mult)
+}
+
+/*
+PiecewiseSchedule is a table of Values where Values[k] starts at update
+Iters[k]; Iters should be in increasing order. Before Iters[0] the value is
+Values[0]. If Interpolate is true the value moves linearly between table
+entries otherwise it steps. Iters without a matching entry of Values take the
+last value and an empty table gives 0.
+*/
+type PiecewiseSchedule struct {
+	Iters       []int
+	Values      []float64
+	Interpolate bool
+}
+
+// Value returns the table value at iter.
+func (s *PiecewiseSchedule) Value(iter int) float64 {
+	n := len(s.Values)
+	if n == 0 {
+		return 0
+	}
+	k := sort.SearchInts(s.Iters, iter+1) - 1
+	if k < 0 {
+		return s.Values[0]
+	}
+	if k+1 >= n {
+		return s.Values[n-1]
+	}
+	if !s.Interpolate || k+1 >= len(s.Iters) {
+		return s.Values[k]
+	}
+	x := float64(iter-s.Iters[k]) / float64(s.Iters[k+1]-s.Iters[k])
+	return s.Values[k] + (s.Values[k+1]-s.Values[k])*x
+}
+
+// About gives a description of the table.
+func (s *PiecewiseSchedule) About() string {
+	return fmt.Sprintf("piecewise table %v at updates %v interpolated=%t",
+		s.Values, s.Iters, s.Interpolate)
+}
+
+// heuristicSchedule attaches a Schedule to a heuristic index.
+type heuristicSchedule struct {
+	// true for an integer heuristic
+	isInt bool
+	index int
+	s     Schedule
+}
+
+// setSchedule replaces the schedule of a heuristic or removes it when s is nil.
+func (pso *Pso) setSchedule(isInt bool, i int, s Schedule) {
+	for k := range pso.schedules {
+		hs := &pso.schedules[k]
+		if hs.isInt == isInt && hs.index == i {
+			if s == nil {
+				pso.schedules = append(pso.schedules[:k], pso.schedules[k+1:]...)
+			} else {
+				hs.s = s
+			}
+			return
+		}
+	}
+	if s != nil {
+		pso.schedules = append(pso.schedules, heuristicSchedule{isInt, i, s})
+	}
+}
+
+// SetFloatSchedule attaches s to the ith floating point heuristic of the
+// master heuristics; s = nil removes the schedule.
+func (pso *Pso) SetFloatSchedule(i int, s Schedule) { pso.setSchedule(false, i, s) }
+
+/*
+SetIntSchedule attaches s to the ith integer heuristic of the master
+heuristics, rounding the value to the nearest integer; s = nil removes the
+schedule. Note CLPso copies TryGapHeuristic to its TryGap field so follows a
+schedule on it.
+*/
+func (pso *Pso) SetIntSchedule(i int, s Schedule) { pso.setSchedule(true, i, s) }
+
+// FloatSchedule returns the schedule of the ith floating point heuristic or
+// nil if there is none.
+func (pso *Pso) FloatSchedule(i int) Schedule { return pso.schedule(false, i) }
+
+// IntSchedule returns the schedule of the ith integer heuristic or nil if
+// there is none.
+func (pso *Pso) IntSchedule(i int) Schedule { return pso.schedule(true, i) }
+
+func (pso *Pso) schedule(isInt bool, i int) Schedule {
+	for _, hs := range pso.schedules {
+		if hs.isInt == isInt && hs.index == i {
+			return hs.s
+		}
+	}
+	return nil
+}
+
+/*
+ApplySchedules sets each scheduled heuristic of the master heuristics to its
+scheduled value for the current update count and then advances the count. It is
+called at the start of Update() by the SPSOs in this package and should be
+called in the same way by other SPSOs built on Pso.
+*/
+func (pso *Pso) ApplySchedules() {
+	for _, hs := range pso.schedules {
+		v := hs.s.Value(pso.updates)
+		if hs.isInt {
+			pso.hu.SetInt(hs.index, int(math.Round(v)))
+		} else {
+			pso.hu.SetFloat(hs.index, v)
+		}
+	}
+	pso.updates++
+}
+
+// Updates returns the number of times ApplySchedules() has been called.
+func (pso *Pso) Updates() int { return pso.updates }
@@ -0,0 +1,58 @@
+package setpso_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+)
+
+func TestSchedule(t *testing.T) {
+	cases := []struct {
+		s    setpso.Schedule
+		iter int
+		want float64
+	}{
+		{&setpso.LinearSchedule{From: 0.9, To: 0.4, Iters: 100}, 0, 0.9},
+		{&setpso.LinearSchedule{From: 0.9, To: 0.4, Iters: 100}, 50, 0.65},
+		{&setpso.LinearSchedule{From: 0.9, To: 0.4, Iters: 100}, 200, 0.4},
+		{&setpso.ExpSchedule{From: 1.0, To: 0.0, Tau: 10}, 10, math.Exp(-1)},
+		{&setpso.CosineSchedule{Max: 1.0, Min: 0.0, Period: 10}, 5, 0.5},
+		{&setpso.CosineSchedule{Max: 1.0, Min: 0.0, Period: 10}, 10, 1.0},
+		{&setpso.CosineSchedule{Max: 1.0, Min: 0.0, Period: 10, Mult: 2}, 20, 0.5},
+		{&setpso.PiecewiseSchedule{Iters: []int{10, 20}, Values: []float64{1, 2}}, 5, 1.0},
+		{&setpso.PiecewiseSchedule{Iters: []int{10, 20}, Values: []float64{1, 2}}, 15, 1.0},
+		{&setpso.PiecewiseSchedule{Iters: []int{10, 20}, Values: []float64{1, 2}, Interpolate: true}, 15, 1.5},
+		{&setpso.PiecewiseSchedule{Iters: []int{10, 20}, Values: []float64{1, 2}}, 20, 2.0},
+		{&setpso.ExpSchedule{From: 1.0, To: 0.5, Tau: 0}, 0, 0.5},
+		{&setpso.ExpSchedule{From: 1.0, To: 0.5, Tau: 0}, 10, 0.5},
+		{&setpso.PiecewiseSchedule{Iters: []int{10, 20}}, 15, 0.0},
+		{&setpso.PiecewiseSchedule{}, 0, 0.0},
+		{&setpso.PiecewiseSchedule{Iters: []int{10, 20, 30}, Values: []float64{1, 2}}, 35, 2.0},
+		{&setpso.PiecewiseSchedule{Iters: []int{10, 20, 30}, Values: []float64{1, 2}, Interpolate: true}, 25, 2.0},
+	}
+	for _, c := range cases {
+		if v := c.s.Value(c.iter); !(math.Abs(v-c.want) <= 1e-12) {
+			t.Errorf("%s: Value(%d) = %f want %f", c.s.About(), c.iter, v, c.want)
+		}
+	}
+
+	pso := setpso.NewGPso(newPso(10))
+	pso.SetFloatSchedule(setpso.OmegaHeuristic, &setpso.LinearSchedule{From: 0.9, To: 0.4, Iters: 10})
+	pso.SetIntSchedule(setpso.NTriesHeuristic, &setpso.LinearSchedule{From: 10, To: 20, Iters: 10})
+	for i := 0; i < 5; i++ {
+		pso.Update()
+	}
+	hu := pso.Heuristics()
+	if v := hu.Float(setpso.OmegaHeuristic); math.Abs(v-0.7) > 1e-12 {
+		t.Errorf("scheduled omega at update 4 is %f want 0.7", v)
+	}
+	if v := hu.Int(setpso.NTriesHeuristic); v != 14 {
+		t.Errorf("scheduled ntries at update 4 is %d want 14", v)
+	}
+	pso.SetFloatSchedule(setpso.OmegaHeuristic, nil)
+	pso.Update()
+	if v := hu.Float(setpso.OmegaHeuristic); math.Abs(v-0.7) > 1e-12 {
+		t.Errorf("omega changed to %f after removing its schedule", v)
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"io"
 	"math"
 	"math/big"
+	"math/bits"
 	"math/rand"
+	"strconv"
+	"sync"
 
 	"github.com/mathrgo/setpso/fun/futil"
 )
@@ -47,7 +50,9 @@ function to either replace this by a new item with its own contribution to cost
 or set the  corresponding item in a hint to zero during a successful
 ToConstraint() call. in this way the cost function  can try out alternative
 items that may result in an improved cost. Typically this feature is not used
-and a call to  Delete() returns false.
+and a call to  Delete() returns false. Pso only calls Delete() when item
+replacement has been turned on by SetItemReplacement(); package
+setpso/fun/poolsum has a cost function that uses it.
 */
 type Fun interface {
 	//creates a new Try which is a pointer to a structure using  a default parameter that should satisfy constraints and updates Try's cost and internal decode.
@@ -133,6 +138,12 @@ type Particle struct {
 	bestTry Try
 	// current  flipping probability requests for each bit component
 	vel []float64
+	// true when the last update replaced bestTry
+	improved bool
+	// sparse form of the velocity used instead of vel when not nil
+	sv *sparseVel
+	// Parameters learnt from in place of the group Targets when not nil
+	exemplar *big.Int
 
 	debug bool
 }
@@ -147,6 +158,11 @@ func (p *Particle) CurrentTry() Try {
 	return p.current
 }
 
+// Improved returns true when the last update replaced the Personal-best try.
+func (p *Particle) Improved() bool {
+	return p.improved
+}
+
 //Group is a collection of particles with same heuristic settings
 type Group struct {
 	//group's id
@@ -155,16 +171,23 @@ type Group struct {
 	members []int
 	// list of update targets by index (normally of length <= 2)
 	targets []int
+	// weights of the targets; a missing weight is taken to be 1.0
+	weights []float64
 	// this gives the index for the  best member in Pt
 	bestMember int
 	// heuristics for the group
 	hu *PsoHeuristics
+	// operation for combining velocity probabilities; nil to use the Pso
+	// default
+	comb Combiner
 }
 
 // Pso is the Particle swarm optimizer
 type Pso struct {
 	// random number generator
 	rnd *rand.Rand
+	// source of rnd which keeps track of the generator position
+	src *countedSource
 	// collection of particles
 	Pt []Particle
 	// mapped collection of groups of particles (with same heuristic settings)
@@ -173,6 +196,16 @@ type Pso struct {
 	temp *big.Int
 	//scratch pad for intermediate velocity calculation
 	tempVel []float64
+	// scratch pad words for the mask of bits to flip and the mask using them
+	flip     []big.Word
+	flipMask *big.Int
+	// cut off for sparse velocities; 0 when velocities are dense
+	eps float64
+	// sparse scratch pad used instead of tempVel for sparse velocities
+	stemp *sparseTemp
+	// scratch pads for rebuilding sparse velocities
+	sidx []int
+	su   []float64
 	// this gives the index of the particle with best try
 	bestParticle int
 	// cost function
@@ -185,6 +218,25 @@ type Pso struct {
 	n int
 	// heuristics for the groups to derive from
 	hu *PsoHeuristics
+	// default operation for combining velocity probabilities
+	comb Combiner
+	// schedules of the master heuristics
+	schedules []heuristicSchedule
+	// number of updates used by the schedules
+	updates int
+	// item usage for item replacement; nil when not in use
+	usage *itemUsage
+	// restart policy state; nil when not in use
+	restart *restartState
+	// archive of distinct good tries; nil when not in use
+	elite *eliteArchive
+	// local search state; nil when not in use
+	local *localSearch
+	// racing of noisy tries; nil when not in use
+	racing *racing
+	// cost function instances used by each worker when evaluating costs
+	// concurrently; empty when costs are evaluated serially
+	workerFun []Fun
 }
 
 /*NominalL returns a nominal LfactorHeuristic value for  the LfactorHeuristic based on
@@ -209,21 +261,36 @@ Func.MaxLen()bits; ToConstraint() is used on these random choices with repeated
 use  of random choice until each Particle has an initial Parameters that
 satisfies the cost function  constraints on the Parameters. Default heuristics
 are applied  to the "root" Group and all particles are added to this group.
-The PSO uses the random generator seed sd.
+The PSO uses the random generator seed sd. Note this never gives up looking for
+constraint satisfying Parameters; use NewPsoInit() to limit the search.
 */
 func NewPso(n int, fun Fun,
 	sd int64) *Pso {
+	pso, _ := NewPsoInit(n, fun, sd, &RandomInit{})
+	return pso
+}
+
+/*
+NewPsoInit sets up a PSO in the same way as NewPso() except that the initial
+Parameters are chosen by the initialization strategy init. It returns an error
+if init fails.
+*/
+func NewPsoInit(n int, fun Fun, sd int64, init Initializer) (*Pso, error) {
 	var pso Pso
-	pso.rnd = rand.New(rand.NewSource(sd))
+	pso.src = newCountedSource(sd)
+	pso.rnd = rand.New(pso.src)
 	pso.n = n
 	pso.maxLen = fun.MaxLen()
 	pso.fun = fun
 	pso.temp = big.NewInt(0)
 	pso.tempVel = make([]float64, pso.maxLen)
+	pso.flip = make([]big.Word, (pso.maxLen+wordBits-1)/wordBits)
+	pso.flipMask = new(big.Int)
 	pso.gr = make(map[string]*Group, n)
 	g := new(Group)
 	pso.gr["root"] = g
 	g.id = "root"
+	pso.comb = PseudoAdd{}
 	pso.hu = pso.CreatePsoHeuristics()
 	pso.SetGroupHeuristics(g, pso.hu)
 	g.members = make([]int, n)
@@ -240,20 +307,20 @@ func NewPso(n int, fun Fun,
 		p.hint = big.NewInt(0)
 		p.current = pso.fun.NewTry()
 		p.bestTry = pso.fun.NewTry()
-		//search for state that satisfies function constraints
-		searching := true
-		for searching {
-			p.hint.Rand(pso.rnd, maxN)
-			searching = !pso.fun.ToConstraint(p.current, p.hint)
-			//fmt.Printf("part= %d param= %v  %s %s \n", i, p.current.Parameter(), p.current.Decode(), p.current.Cost())
-		}
-		pso.fun.Copy(p.bestTry, p.current)
 		g.members[i] = i
 		p.vel = make([]float64, pso.maxLen)
 
 	}
+	//search for states that satisfy function constraints
+	if err := init.Init(&pso, pso.rnd); err != nil {
+		return nil, err
+	}
+	for i := range pso.Pt {
+		p := &pso.Pt[i]
+		pso.fun.Copy(p.bestTry, p.current)
+	}
 	pso.UpdateGlobal()
-	return &pso
+	return &pso, nil
 }
 
 //Part returns  ith particle
@@ -267,13 +334,14 @@ Note that it looks for the best in the current iteration and disregards historic
 best costs even if they were better.
 */
 func (pso *Pso) UpdateGroup(g *Group) {
-	if len(g.members) == 0 {
-		g.bestMember = -1
-		return
-	}
-	g.bestMember = g.members[0]
+	// a group that all its members have left has no best member
+	g.bestMember = -1
 	for i := range g.members {
 		id := g.members[i]
+		if g.bestMember < 0 {
+			g.bestMember = id
+			continue
+		}
 		result := pso.fun.Cmp(pso.Pt[g.bestMember].bestTry,
 			pso.Pt[id].bestTry, futil.CostMode)
 		if result > 0.0 {
@@ -295,7 +363,7 @@ func (pso *Pso) UpdateGlobal() {
 	}
 	pso.bestParticle = 0
 	for _, g := range pso.gr {
-		if len(g.members) > 0 {
+		if g.bestMember >= 0 {
 			//fmt.Printf("bestmember= %d", g.bestMember)
 			compResult := pso.fun.Cmp(pso.Pt[pso.bestParticle].bestTry,
 				pso.Pt[g.bestMember].bestTry, futil.CostMode)
@@ -318,6 +386,23 @@ func (pso *Pso) Nparticles() int {
 	return len(pso.Pt)
 }
 
+// Base returns the Pso itself, which gives access to the Pso underlying a
+// derived SPSO.
+func (pso *Pso) Base() *Pso { return pso }
+
+/*
+SetParticle sets the current and Personal-best Parameters of the ith particle
+to x and clears its list of tries and its velocity. x should satisfy the cost
+function constraints. Call UpdateGlobal() after setting particles.
+*/
+func (pso *Pso) SetParticle(i int, x *big.Int) {
+	p := &pso.Pt[i]
+	pso.fun.SetTry(p.current, x)
+	pso.fun.Copy(p.bestTry, p.current)
+	p.tries = p.tries[:0]
+	p.clearVel()
+}
+
 //CurrentTry returns the current try for the ith particle
 func (pso *Pso) CurrentTry(i int) Try {
 	return pso.Pt[i].current
@@ -334,12 +419,19 @@ func (pso *Pso) Heuristics() *PsoHeuristics { return pso.hu }
 // CardinalSize returns the number of 1's in a binary representation of Int
 // (assuming it is positive) so is the cardinal size of the corresponding set.
 func CardinalSize(x *big.Int) (card int) {
-	card = 0
-	j := x.BitLen()
-	for i := 0; i < j; i++ {
-		if x.Bit(i) == 1 {
-			card++
+	if x.Sign() < 0 {
+		// count the bits of the two's complement as Bit() does
+		card = 0
+		j := x.BitLen()
+		for i := 0; i < j; i++ {
+			if x.Bit(i) == 1 {
+				card++
+			}
 		}
+		return
+	}
+	for _, w := range x.Bits() {
+		card += bits.OnesCount(uint(w))
 	}
 	return
 }
@@ -351,17 +443,23 @@ Parameters. The blur is effected indirectly  by pseudo adding a probability
 
   pb =rand*(l*CardinalSize(x)+l0)/MaxLen()
 
-to each component of the Velocity;l,l0 corresponds to the LfactorHeuristic, LoffsetHeuristic
+to each component of the Velocity using the Combiner of the particle's group;
+l,l0 corresponds to the LfactorHeuristic, LoffsetHeuristic
 heuristics;MaxLen() is the maximum number of set items ;rand is a random number
 between 0 and 1.
 */
 func (pso *Pso) BlurTarget(x *big.Int, id int, l, l0 float64) {
 	h := l*float64(CardinalSize(x)) + l0
 	p := &pso.Pt[id]
+	c := pso.GroupCombiner(p.group)
 	// add blur via velocity increment
 	prob := pso.rnd.Float64() * h / float64(pso.maxLen)
+	if p.sv != nil {
+		p.sv.blur(prob, c)
+		return
+	}
 	for i := range p.vel {
-		p.vel[i] = p.vel[i]*(1.0-prob) + prob
+		p.vel[i] = c.Combine(p.vel[i], prob)
 	}
 }
 
@@ -371,26 +469,39 @@ cost and  personal best case. It also revaluates the personal best cost in
 case the function has changed.
 */
 func (pso *Pso) SetParams(id int) {
+	pso.setParams(id, pso.fun)
+}
+
+// setParams does the work of SetParams() using the cost function f, which is
+// either pso.fun or a worker's own instance of the cost function.
+func (pso *Pso) setParams(id int, f Fun) {
 	p := &pso.Pt[id]
-	pso.fun.UpdateCost(p.bestTry)
+	p.improved = false
+	if pso.racing != nil {
+		// the Personal-best is decided by race()
+		pso.racing.valid[id] = f.ToConstraint(p.current, p.hint)
+		return
+	}
+	f.UpdateCost(p.bestTry)
 	// update cost if the hint can be converted to a constraint satisfying
 	// subset
-	if pso.fun.ToConstraint(p.current, p.hint) {
+	if f.ToConstraint(p.current, p.hint) {
 		// if p.debug {
 		// 	fmt.Printf("constraint update part= %d  %s %s \n", id, p.bestTry.Decode(), p.bestTry.Cost())
 		// 	p.debug = false
 		// }
 
 		for i := range p.tries {
-			pso.fun.UpdateCost(p.tries[i])
+			f.UpdateCost(p.tries[i])
 		}
-		p.lookForBetterTry(pso)
-		compResult := pso.fun.Cmp(p.bestTry, p.current, futil.CostMode)
+		p.lookForBetterTry(f)
+		compResult := f.Cmp(p.bestTry, p.current, futil.CostMode)
 		//fmt.Printf("compResult = %f \n", compResult)
 
 		if compResult > pso.hu.Float(ThresholdHeuristic) {
 			//p.putOntoTryList(pso, p.bestTry)
-			pso.fun.Copy(p.bestTry, p.current)
+			f.Copy(p.bestTry, p.current)
+			p.improved = true
 			//fmt.Printf("part= %d  %s %s \n", id, p.bestTry.Decode(), p.bestTry.Cost())
 			// if p.bestTry.Fbits() < 8.2 {
 			// 	fmt.Printf("part= %d  %s %s \n", id, p.bestTry.Decode(), p.bestTry.Cost())
@@ -401,44 +512,114 @@ func (pso *Pso) SetParams(id int) {
 			//}
 
 		} else if compResult > -pso.hu.Float(ThresholdHeuristic) {
-			p.putOntoTryList(pso, p.current)
+			p.putOntoTryList(f, p.current, pso.hu.Int(NTriesHeuristic))
+		}
+	}
+}
+
+/*
+SetWorkers sets up the concurrent evaluation of particle costs in PUpdate()
+using a pool of n workers. Since cost functions usually hold scratch state each
+worker has its own instance of the cost function created by calling newFun(),
+which should return a cost function that behaves identically to the one given
+to NewPso(). The particles are shared out between the workers in a fixed way so
+for a deterministic cost function the run is the same as the serial run for the
+same seed. Cost functions with noisy costs, such as those using
+futil.SFloatFunStub or futil.VFloatFunStub, draw their noise from a random
+generator of their own, so each worker would draw a different noise sequence;
+for these an error is returned and the evaluation stays serial. Other cost
+functions that draw random numbers while costing also give runs that differ
+from the serial run. Setting n <= 1 returns to serial evaluation.
+*/
+func (pso *Pso) SetWorkers(n int, newFun func() Fun) error {
+	pso.workerFun = nil
+	if n <= 1 {
+		return nil
+	}
+	if isNoisy(pso.fun) {
+		return fmt.Errorf("cost-function %T has noisy costs so cannot be shared between workers", pso.fun)
+	}
+	pso.workerFun = make([]Fun, n)
+	for i := range pso.workerFun {
+		pso.workerFun[i] = newFun()
+	}
+	return nil
+}
+
+// Workers returns the number of workers used to evaluate particle costs.
+func (pso *Pso) Workers() int {
+	if len(pso.workerFun) == 0 {
+		return 1
+	}
+	return len(pso.workerFun)
+}
+
+// setAllParams calls SetParams() for every particle either serially or
+// by sharing out the particles between the workers, followed by race() when
+// racing is turned on.
+func (pso *Pso) setAllParams() {
+	nw := len(pso.workerFun)
+	if nw == 0 {
+		for i := range pso.Pt {
+			pso.SetParams(i)
 		}
+	} else {
+		pso.setAllParamsWorkers(nw)
+	}
+	if pso.racing != nil {
+		pso.race()
+	}
+}
+
+// setAllParamsWorkers shares out the particles between the nw workers.
+func (pso *Pso) setAllParamsWorkers(nw int) {
+	var wg sync.WaitGroup
+	wg.Add(nw)
+	for w := range pso.workerFun {
+		go func(w int) {
+			defer wg.Done()
+			f := pso.workerFun[w]
+			for i := w; i < len(pso.Pt); i += nw {
+				pso.setParams(i, f)
+			}
+		}(w)
 	}
+	wg.Wait()
 }
 
-func (p *Particle) putOntoTryList(pso *Pso, t Try) {
-	try := pso.fun.NewTry()
-	pso.fun.Copy(try, t)
+func (p *Particle) putOntoTryList(f Fun, t Try, ntries int) {
+	try := f.NewTry()
+	f.Copy(try, t)
 	p.tries = append(p.tries, try)
-	if len(p.tries) > pso.hu.Int(NTriesHeuristic) {
-		p.removeWorstTry(pso)
+	if len(p.tries) > ntries {
+		p.removeWorstTry(f)
 	}
 }
 
-func (p *Particle) lookForBetterTry(pso *Pso) {
+func (p *Particle) lookForBetterTry(f Fun) {
 	// if len(p.tries)>0{
 	// 	fmt.Printf("trys len = %d \n",len(p.tries))
 	// }
 	j := -1
 	betterResult := 0.0
 	for i := range p.tries {
-		result := pso.fun.Cmp(p.bestTry, p.tries[i], futil.TriesMode)
+		result := f.Cmp(p.bestTry, p.tries[i], futil.TriesMode)
 		if result > betterResult {
 			j = i
 			betterResult = result
 		}
 	}
 	if j >= 0 && betterResult > 1.0 {
-		pso.fun.Copy(p.bestTry, p.tries[j])
+		f.Copy(p.bestTry, p.tries[j])
 		p.tries = append(p.tries[:j], p.tries[j+1:]...)
 	}
 }
 
-func (p *Particle) removeWorstTry(pso *Pso) {
+func (p *Particle) removeWorstTry(f Fun) {
 	j := -1
 	worstResult := math.MaxFloat64
 	for i := range p.tries {
-		result := pso.fun.Cmp(p.bestTry, p.tries[i], futil.TriesMode)
+		result := f.Cmp(p.bestTry, p.tries[i], futil.TriesMode)
 		if result < worstResult {
 			j = i
 			worstResult = result
@@ -449,28 +630,56 @@ func (p *Particle) removeWorstTry(pso *Pso) {
 	}
 }
 
-func (pso *Pso) setTempVel(z *big.Int, prob float64) {
-	for i := range pso.tempVel {
-		if z.Bit(i) > 0 {
-			pso.tempVel[i] = prob
-		} else {
-			pso.tempVel[i] = 0.0
-		}
+func (pso *Pso) setTempVel(z *big.Int, prob float64, c Combiner) {
+	if pso.stemp != nil {
+		pso.stemp.reset()
+		pso.stemp.add(z, pso.maxLen, prob, c)
+		return
 	}
-}
-func (pso *Pso) addToTempVel(z *big.Int, prob float64) {
 	for i := range pso.tempVel {
-		if z.Bit(i) > 0 {
-			pso.tempVel[i] = pso.tempVel[i]*(1.0-prob) + prob
+		pso.tempVel[i] = 0.0
+	}
+	v := c.Combine(0.0, prob)
+	ForEachBit(z, func(i int) {
+		if i < len(pso.tempVel) {
+			pso.tempVel[i] = v
 		}
+	})
+}
+func (pso *Pso) addToTempVel(z *big.Int, prob float64, c Combiner) {
+	if pso.stemp != nil {
+		pso.stemp.add(z, pso.maxLen, prob, c)
+		return
 	}
+	ForEachBit(z, func(i int) {
+		if i < len(pso.tempVel) {
+			pso.tempVel[i] = c.Combine(pso.tempVel[i], prob)
+		}
+	})
 }
-func (p *Particle) addToVel(z *big.Int, prob float64) {
-	for i := range p.vel {
-		if z.Bit(i) > 0 {
-			p.vel[i] = p.vel[i]*(1.0-prob) + prob
+func (p *Particle) addToVel(z *big.Int, prob float64, c Combiner) {
+	ForEachBit(z, func(i int) {
+		if i < len(p.vel) {
+			p.vel[i] = c.Combine(p.vel[i], prob)
 		}
+	})
+}
+
+// addTarget adds the velocity contribution of the Parameters x with weight w
+// to the temporary velocity of the kth particle.
+func (pso *Pso) addTarget(k int, x *big.Int, w, l, l0, phi float64, c Combiner) {
+	p := &pso.Pt[k]
+	pso.temp.Xor(x, p.current.Parameter())
+	pso.BlurTarget(pso.temp, k, w*l, w*l0)
+	rg := w * phi * pso.rnd.Float64()
+	if rg > 1 {
+		rg = 2 - rg
 	}
+	if rg < 0 {
+		rg = 0
+	}
+	// add target best velocity contribution
+	pso.addToTempVel(pso.temp, rg, c)
 }
 
 /*
@@ -487,16 +696,27 @@ for each exclusive or between 0 and PhiHeuristic; each r is checked to be <= 1.0
 forced  to be so by replacing by 2-r if it isn't. These r are then pseudo added
 to the  the velocity components that have an exclusive or bit of 1.
 
+Probabilities are pseudo added by default but the operation can be changed
+for the whole swarm using SetCombiner() or for a group using
+SetGroupCombiner().
+
+Each Target can be given a weight w using SetGroupWeightedTargets(), in which
+case its blur heuristics and PhiHeuristic are scaled by w; by default w = 1.0.
+A particle given an exemplar by SetExemplar() learns from it, with w = 1.0, in
+place of its Targets.
+
 In this way the Velocity components are computed to encourage movement toward
 Personal-best and Targets after encouraging more mutation for distant Targets
 and Personal-best Parameters.
 
-After this Setparams() and UpdateGlobal() are called to finish the update.
+After this Setparams() is called, concurrently when workers have been set up
+using SetWorkers(), and the update is finished as described for endUpdate().
 */
 func (pso *Pso) PUpdate() {
 	for k := range pso.Pt {
 		p := &pso.Pt[k]
 		g := p.group
+		c := pso.GroupCombiner(g)
 		PhiHeuristic := g.hu.Float(PhiHeuristic)
 		l := g.hu.Float(LfactorHeuristic)
 		l0 := g.hu.Float(LoffsetHeuristic)
@@ -508,43 +728,68 @@ func (pso *Pso) PUpdate() {
 			rp = 2 - rp
 		}
 		// add personal best velocity contribution
-		pso.setTempVel(pso.temp, rp)
-		for t := range g.targets {
-			target := g.targets[t]
-			pso.temp.Xor(pso.Pt[target].bestTry.Parameter(),
-				p.current.Parameter())
-			pso.BlurTarget(pso.temp, k, l, l0)
-			rg := PhiHeuristic * pso.rnd.Float64()
-			if rg > 1 {
-				rg = 2 - rg
+		pso.setTempVel(pso.temp, rp, c)
+		if p.exemplar != nil {
+			pso.addTarget(k, p.exemplar, 1.0, l, l0, PhiHeuristic, c)
+		} else {
+			for t := range g.targets {
+				pso.addTarget(k, pso.Pt[g.targets[t]].bestTry.Parameter(),
+					g.Weight(t), l, l0, PhiHeuristic, c)
 			}
-			// add target best velocity contribution
-			pso.addToTempVel(pso.temp, rg)
 		}
 		//reduce velocity and then combine contributions
 		OmegaHeuristic := g.hu.Float(OmegaHeuristic)
+		if p.sv != nil {
+			pso.sidx, pso.su = p.sv.combine(pso.stemp, OmegaHeuristic, pso.eps, c, pso.sidx, pso.su)
+		}
 		for jv := range p.vel {
 			p.vel[jv] *= OmegaHeuristic
-			p.vel[jv] = (1.0-pso.tempVel[jv])*p.vel[jv] + pso.tempVel[jv]
+			p.vel[jv] = c.Combine(p.vel[jv], pso.tempVel[jv])
 		}
 
-		// update parameter
-		p.hint.Set(p.current.Parameter())
+		// update parameter by flipping the bits in a mask
+		for i := range pso.flip {
+			pso.flip[i] = 0
+		}
+		if p.sv != nil {
+			pso.sidx, pso.su = p.sv.flip(pso.rnd, pso.flip, pso.maxLen, pso.sidx, pso.su)
+		}
 		for jv := range p.vel {
 			if pso.rnd.Float64() < p.vel[jv] {
 				p.vel[jv] = 0.0
-				if p.hint.Bit(jv) == 0 {
-					p.hint.SetBit(p.hint, jv, 1)
-				} else {
-					p.hint.SetBit(p.hint, jv, 0)
-				}
+				setWordBit(pso.flip, jv)
 			}
 		}
+		pso.flipMask.SetBits(pso.flip)
+		p.hint.Xor(p.current.Parameter(), pso.flipMask)
 	}
-	for i := range pso.Pt {
-		pso.SetParams(i)
+	pso.setAllParams()
+	pso.endUpdate()
+}
+
+/*
+endUpdate finishes an update once the Personal-bests have been set by calling
+UpdateGlobal(). When item replacement is turned on by SetItemReplacement()
+unused items are replaced before UpdateGlobal(). When restarts are turned on by
+SetRestart() a stagnant swarm is partly restarted after UpdateGlobal() and the
+Personal-bests are offered to the archive turned on by SetEliteArchive() before
+this. Before both of these the best Personal-bests are improved by the local
+search turned on by SetLocalSearch().
+*/
+func (pso *Pso) endUpdate() {
+	if pso.usage != nil {
+		pso.replaceItems()
 	}
 	pso.UpdateGlobal()
+	if pso.local != nil {
+		pso.localSearch()
+	}
+	if pso.elite != nil {
+		pso.offerElite()
+	}
+	if pso.restart != nil {
+		pso.checkRestart()
+	}
 }
 
 //CreateGroup creates a group named 'name' with a slot for 'ntargets' targets.
@@ -569,7 +814,8 @@ func (pso *Pso) Group(id int) *Group {
 	return pso.Pt[id].group
 }
 
-//MoveTo moves the particle 'pat' to the designated group
+//MoveTo moves the particle 'pat' to the designated group, removing it from the
+//members of its old group; it does nothing if 'pat' is not found there.
 func (pso *Pso) MoveTo(g *Group, pat int) {
 	g0 := pso.Pt[pat].group
 	// find pat in group members and delete
@@ -580,11 +826,14 @@ func (pso *Pso) MoveTo(g *Group, pat int) {
 			break
 		}
 	}
+	if j < 0 {
+		return
+	}
 	l := len(g0.members)
 	for ; j < l-1; j++ {
 		g0.members[j] = g0.members[j+1]
 	}
-	g0.members = g0.members[:l]
+	g0.members = g0.members[:l-1]
 	g.members = append(g.members, pat)
 	pso.Pt[pat].group = g
 
@@ -677,6 +926,13 @@ func (hu *PsoHeuristics) SetInt(i, x int) {
 	hu.intValues[i] = x
 }
 
+// Clone returns a copy of the heuristics that can be changed independently.
+func (hu *PsoHeuristics) Clone() *PsoHeuristics {
+	return &PsoHeuristics{
+		floatValues: append([]float64(nil), hu.floatValues...),
+		intValues:   append([]int(nil), hu.intValues...)}
+}
+
 /*
 Heuristics returns the pointer to the group's heuristics
 */
@@ -708,6 +964,41 @@ func (pso *Pso) SetGroupTarget(grp *Group, targetList ...int) {
 	copy(grp.targets, targetList)
 }
 
+/*
+SetExemplar sets the Parameters x that particle id learns from in place of the
+Personal-bests of its group's Targets, so that different bits can be learnt
+from different particles. x is not copied and nil goes back to learning from
+the Targets.
+*/
+func (pso *Pso) SetExemplar(id int, x *big.Int) {
+	pso.Pt[id].exemplar = x
+}
+
+/*
+SetGroupWeightedTargets replaces the Targets of group 'grp' by the particle list
+targetList, which can be of any length, where the ith target has the weight
+weights[i]. A weight scales the target's contribution to the velocity update in
+PUpdate(). If weights is shorter than targetList the missing weights are 1.0.
+*/
+func (pso *Pso) SetGroupWeightedTargets(grp *Group, targetList []int, weights []float64) {
+	grp.targets = append(grp.targets[:0], targetList...)
+	grp.weights = append(grp.weights[:0], weights...)
+}
+
+// Targets returns the list of Targets of the group.
+func (g *Group) Targets() []int { return g.targets }
+
+// Members returns the list of particles in the group.
+func (g *Group) Members() []int { return g.members }
+
+// Weight returns the weight of the ith target of the group.
+func (g *Group) Weight(i int) float64 {
+	if i < len(g.weights) {
+		return g.weights[i]
+	}
+	return 1.0
+}
+
 // GroupBest returns the best particle id in the group grp.
 // It returns -1 if there is no member.
 func (pso *Pso) GroupBest(grp *Group) (best int) {
@@ -732,6 +1023,7 @@ func (p *GPso) SetHeuristics(hu *PsoHeuristics) { p.hu = hu }
 // Update sets the target to the current global best  Particle before updating
 // the particle swarm
 func (p *GPso) Update() {
+	p.ApplySchedules()
 	g := p.Group(0)
 	p.SetGroupTarget(g, p.GroupBest(g))
 	p.PUpdate()
@@ -740,12 +1032,18 @@ func (p *GPso) Update() {
 /*CLPso is a comprehensive learning PSO that targets other personal best
 rather than just the global best. Each particle has its own group with group name
 set to the particles id and has one target. All particles share Heuristics.
+
+When Winners > 0 the CLPso learns dimension-wise, as created by NewCLPso1(), so
+that each bit of a particle's exemplar can come from a different one of Winners
+tournament winners.
 */
 type CLPso struct {
 	*Pso
 	clPt []CLpart
 	//target refreshing gap
 	TryGap int
+	// number of tournament winners in an exemplar; 0 for whole-particle learning
+	Winners int
 }
 
 //CLpart includes the additional particle state used by CLPso to manage particle
@@ -757,13 +1055,18 @@ type CLpart struct {
 	lastBest Try
 	// failure to improve count while pursuing current target
 	gapCount int
+	// tournament winners learnt from in dimension-wise learning
+	winners []int
+	// bits learnt from each winner
+	masks []*big.Int
+	// exemplar built from the Personal-bests of the winners
+	exemplar *big.Int
 }
 
 /*
 Pc0 calculates the assigned probability of learning from other particles. i  is the
 Particle id and n is the number of particles. The formula is an empirical one
-used in the continuous case. This is likely to be replaced by another
-empirical formula called Pc1 and Pc0 is just a stand in for Pc1.
+used in the continuous case. Pc1 replaces it for dimension-wise learning.
 */
 func Pc0(i int, n float64) float64 {
 	e0 := (math.Exp(10.0) - 1.0)
@@ -771,6 +1074,23 @@ func Pc0(i int, n float64) float64 {
 	return 0.05 + 0.45*x
 }
 
+/*
+Pc1 calculates the assigned probability that a bit is learnt from another
+particle in dimension-wise learning. i is the Particle id and n is the number
+of particles. It has the same form as Pc0 but with a flatter curve and a range
+of 0.005 to 0.05, a tenth of that of Pc0, since a bit is learnt for each
+Parameter item rather than the whole Parameter at once. The values were tuned
+by trial on subset-sum problems.
+*/
+func Pc1(i int, n float64) float64 {
+	if n < 2 {
+		return 0.005
+	}
+	e0 := (math.Exp(5.0) - 1.0)
+	x := (math.Exp(5*float64(i)/(n-1.0)) - 1.0) / e0
+	return 0.005 + 0.045*x
+}
+
 /*
 NewCLPso creates a CLPso and sets the heuristics if required. In this case
 TryGapHeuristic is the number of iterations with no improvement needed to
@@ -780,7 +1100,7 @@ triggered, where n is the number of particles.
 */
 func NewCLPso(p *Pso) *CLPso {
 	clpt := make([]CLpart, p.Nparticles())
-	pso := &CLPso{p, clpt, 0}
+	pso := &CLPso{Pso: p, clPt: clpt}
 	n := float64(pso.Nparticles())
 	pso.TryGap = pso.hu.Int(TryGapHeuristic)
 	for i := range pso.clPt {
@@ -788,12 +1108,99 @@ func NewCLPso(p *Pso) *CLPso {
 		c.pc = Pc0(i, n)
 		c.lastBest = pso.fun.NewTry()
 		c.gapCount = -1 // play safe
-		gp := pso.CreateGroup(string(i), 1)
+		gp := pso.CreateGroup(strconv.Itoa(i), 1)
 		pso.MoveTo(gp, i)
 	}
 	return pso
 }
 
+/*
+NewCLPso1 creates a CLPso that learns dimension-wise from exemplars built from
+the given number of tournament winners, which is at least 1. The ith particle
+is assigned the probability Pc1(i,n) of learning each bit from one of the
+winners. Each particle is given its first exemplar straight away.
+*/
+func NewCLPso1(p *Pso, winners int) *CLPso {
+	pso := NewCLPso(p)
+	if winners < 1 {
+		winners = 1
+	}
+	pso.Winners = winners
+	n := float64(pso.Nparticles())
+	for i := range pso.clPt {
+		c := &pso.clPt[i]
+		c.pc = Pc1(i, n)
+		c.winners = make([]int, winners)
+		c.masks = make([]*big.Int, winners)
+		for k := range c.masks {
+			c.masks[k] = new(big.Int)
+		}
+		c.exemplar = new(big.Int)
+		pso.newExemplar(i)
+		pso.SetExemplar(i, c.exemplar)
+	}
+	return pso
+}
+
+// tournament returns the one of two particles other than i, chosen at random,
+// that has the least Personal-best cost.
+func (p *CLPso) tournament(i int) int {
+	n := p.Nparticles()
+	if n < 2 {
+		return i
+	}
+	i1 := p.rnd.Intn(n - 1)
+	if i1 >= i {
+		i1++
+	}
+	i2 := p.rnd.Intn(n - 1)
+	if i2 >= i {
+		i2++
+	}
+	if p.fun.Cmp(p.Pt[i1].bestTry, p.Pt[i2].bestTry, futil.CostMode) > 0.0 {
+		return i2
+	}
+	return i1
+}
+
+/*
+newExemplar chooses new tournament winners for the ith particle and assigns
+each bit to one of them, at random, with probability pc. If no bit is assigned
+one bit is assigned at random so that the particle always learns from another.
+*/
+func (p *CLPso) newExemplar(i int) {
+	c := &p.clPt[i]
+	for k := range c.winners {
+		c.winners[k] = p.tournament(i)
+		c.masks[k].SetInt64(0)
+	}
+	learnt := false
+	for b := 0; b < p.maxLen; b++ {
+		if p.rnd.Float64() < c.pc {
+			m := c.masks[p.rnd.Intn(len(c.masks))]
+			m.SetBit(m, b, 1)
+			learnt = true
+		}
+	}
+	if !learnt && p.maxLen > 0 {
+		m := c.masks[p.rnd.Intn(len(c.masks))]
+		m.SetBit(m, p.rnd.Intn(p.maxLen), 1)
+	}
+}
+
+// buildExemplar builds the exemplar of the ith particle from its own
+// Personal-best and the bits learnt from the current Personal-bests of its
+// winners.
+func (p *CLPso) buildExemplar(i int) {
+	c := &p.clPt[i]
+	c.exemplar.Set(p.Pt[i].bestTry.Parameter())
+	for k, j := range c.winners {
+		p.temp.Xor(c.exemplar, p.Pt[j].bestTry.Parameter())
+		p.temp.And(p.temp, c.masks[k])
+		c.exemplar.Xor(c.exemplar, p.temp)
+	}
+}
+
 //SetHeuristics sets the heuristics for the particle swarm.
 func (p *CLPso) SetHeuristics(hu *PsoHeuristics) { p.hu = hu }
 
@@ -804,9 +1211,19 @@ the last improvement for at least TryGap iterations. If so it looks for an
 alternative target with probability pc=Pc0(). If it looks for an alternative
 target it randomly selects two Particles and chooses the one  that gives the
 least of the Local-best Cost. Note the choice is from all particles and can
-include itself. After this it does the usual PUpdate().
+include itself.
+
+In dimension-wise learning the check instead gives the particle a new exemplar
+built from Winners tournament winners, each chosen from the other particles,
+where each bit is learnt from one of the winners with probability pc=Pc1().
+The exemplars are rebuilt from the current Personal-bests of the winners on
+every update. After this it does the usual PUpdate().
 */
 func (p *CLPso) Update() {
+	p.ApplySchedules()
+	if p.IntSchedule(TryGapHeuristic) != nil {
+		p.TryGap = p.hu.Int(TryGapHeuristic)
+	}
 	for i := range p.clPt {
 		c := &p.clPt[i]
 		p.fun.UpdateCost(c.lastBest)
@@ -815,7 +1232,9 @@ func (p *CLPso) Update() {
 		if c.gapCount > p.TryGap {
 			c.gapCount = 0
 			p.fun.Copy(c.lastBest, try)
-			if p.rnd.Float64() < c.pc {
+			if c.exemplar != nil {
+				p.newExemplar(i)
+			} else if p.rnd.Float64() < c.pc {
 				i1 := p.rnd.Intn(p.Nparticles())
 				i2 := p.rnd.Intn(p.Nparticles())
 				compResult := p.fun.Cmp(p.Pt[i1].bestTry, p.Pt[i2].bestTry, futil.CostMode)
@@ -839,6 +1258,9 @@ func (p *CLPso) Update() {
 				c.gapCount++
 			}
 		}
+		if c.exemplar != nil {
+			p.buildExemplar(i)
+		}
 	}
 	p.PUpdate()
 }
@@ -848,7 +1270,7 @@ It has the following values and output:
   Command | Output
 	===============================
 	group   | group data for each Particle
-	group0  | "root" group data's best member and cost
+	group0  | "root" group data's best member and cost, or "no members"
 	Pt      | particle local-best cost and parameter and
 				  |  current parameter for each particle
 	vel     | velocity for each particle
@@ -864,6 +1286,12 @@ func (pso *Pso) PrintDebug(w io.Writer, id string) {
 		}
 	case "group0":
 		g := pso.gr["root"]
+		if g == nil || g.bestMember < 0 {
+			// SPSOs that move all the particles into groups of their own
+			// leave the root group empty
+			fmt.Fprintf(w, "no members\n")
+			return
+		}
 		fmt.Fprintf(w, "best member = %d,cost = %v \n",
 			g.bestMember, pso.Pt[g.bestMember].bestTry.Cost())
 	case "Pt":
@@ -880,8 +1308,8 @@ func (pso *Pso) PrintDebug(w io.Writer, id string) {
 			p := &pso.Pt[i]
 			cnt := 0
 			fmt.Fprintf(w, "vel %d\n", i)
-			for j := range p.vel {
-				fmt.Fprintf(w, "  %f", p.vel[j])
+			for _, v := range p.velocity(pso.maxLen) {
+				fmt.Fprintf(w, "  %f", v)
 				cnt++
 				if cnt == 5 {
 					fmt.Fprintf(w, "\n")
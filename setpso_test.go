@@ -0,0 +1,115 @@
+package setpso_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/futil"
+	"github.com/mathrgo/setpso/fun/multimode"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+// newPso returns a swarm of np particles on the subset sum problem used by
+// most of the tests.
+func newPso(np int) *setpso.Pso {
+	return setpso.NewPso(np, subsetsum.New(100, 20, 3142), 578)
+}
+
+// runGPso runs a GPso on a subset sum problem for niter iterations using nw
+// workers and returns the swarm.
+func runGPso(nw, niter int) *setpso.GPso {
+	p0 := newPso(20)
+	p0.SetWorkers(nw, func() setpso.Fun { return subsetsum.New(100, 20, 3142) })
+	p := setpso.NewGPso(p0)
+	for i := 0; i < niter; i++ {
+		p.Update()
+	}
+	return p
+}
+
+func TestSetWorkers(t *testing.T) {
+	serial := runGPso(1, 200)
+	if serial.Workers() != 1 {
+		t.Errorf("serial run has %d workers", serial.Workers())
+	}
+	for _, nw := range []int{2, 3, 8} {
+		p := runGPso(nw, 200)
+		if p.Workers() != nw {
+			t.Errorf("expected %d workers got %d", nw, p.Workers())
+		}
+		for i := 0; i < p.Nparticles(); i++ {
+			x := serial.LocalBestTry(i).Parameter()
+			y := p.LocalBestTry(i).Parameter()
+			if x.Cmp(y) != 0 {
+				t.Errorf("workers=%d particle %d personal best %v differs from serial %v",
+					nw, i, y, x)
+			}
+		}
+		if p.BestParticle() != serial.BestParticle() {
+			t.Errorf("workers=%d best particle %d differs from serial %d",
+				nw, p.BestParticle(), serial.BestParticle())
+		}
+	}
+	// noisy costs would differ from the serial run so are refused
+	noisy := func() setpso.Fun { return multimode.NewFun(4, 16, 0.1, 0.2, 100, 2, 3142) }
+	p0 := setpso.NewPso(20, noisy(), 578)
+	if err := p0.SetWorkers(4, noisy); err == nil {
+		t.Error("workers set up for noisy cost-function")
+	}
+	if p0.Workers() != 1 {
+		t.Errorf("noisy cost-function has %d workers", p0.Workers())
+	}
+}
+
+func TestMoveTo(t *testing.T) {
+	pso := setpso.NewPso(10, subsetsum.New(20, 10, 3142), 578)
+	g := pso.CreateGroup("g", 1)
+	for _, i := range []int{9, 3, 0, 3} {
+		pso.MoveTo(g, i)
+	}
+	// moving a particle leaves no copy of the old group's last member behind
+	if m := pso.Gr("root").Members(); !reflect.DeepEqual(m, []int{1, 2, 4, 5, 6, 7, 8}) {
+		t.Errorf("root group has members %v", m)
+	}
+	if m := g.Members(); !reflect.DeepEqual(m, []int{9, 0, 3}) {
+		t.Errorf("new group has members %v", m)
+	}
+	// LPso empties the root group, which then has no best member
+	lp := setpso.NewLPso(setpso.NewPso(10, subsetsum.New(20, 10, 3142), 578),
+		&setpso.RingTopology{K: 1})
+	lp.Update()
+	if b := lp.GroupBest(lp.Gr("root")); b != -1 {
+		t.Errorf("empty root group has best member %d", b)
+	}
+	var buf bytes.Buffer
+	lp.PrintDebug(&buf, "group0")
+	if buf.String() != "no members\n" {
+		t.Errorf("group0 debug output of an empty root group is %q", buf.String())
+	}
+}
+
+func TestCLPso1(t *testing.T) {
+	n := 20.0
+	for i := 0; i < int(n); i++ {
+		pc := setpso.Pc1(i, n)
+		if pc < 0.005-1e-12 || pc > 0.05+1e-12 {
+			t.Errorf("Pc1(%d) = %f is out of range", i, pc)
+		}
+		if i > 0 && pc <= setpso.Pc1(i-1, n) {
+			t.Errorf("Pc1(%d) = %f is not increasing", i, pc)
+		}
+	}
+	f := subsetsum.New(100, 20, 3142)
+	pso := setpso.NewCLPso1(newPso(20), 4)
+	pso.TryGap = 5
+	start := f.NewTry()
+	f.Copy(start, pso.LocalBestTry(pso.BestParticle()))
+	for i := 0; i < 200; i++ {
+		pso.Update()
+	}
+	if gb := pso.LocalBestTry(pso.BestParticle()); f.Cmp(gb, start, futil.CostMode) >= 0.0 {
+		t.Errorf("no improvement from cost %s", start.Cost())
+	}
+}
@@ -0,0 +1,356 @@
+package setpso
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"sort"
+)
+
+/*
+sparseVel is the sparse form of a particle's velocity. Bits with an entry have
+the flipping probability scale*u[k] for the entry k and all other bits have the
+background probability bg, which comes from target blurring. Omega decay is
+applied lazily by reducing scale.
+*/
+type sparseVel struct {
+	// bit indexes of the entries in increasing order
+	idx []int
+	// entry values before scaling
+	u []float64
+	// scale applied to all entries
+	scale float64
+	// probability of the bits without an entry
+	bg float64
+}
+
+// sparseTemp is a sparse scratch pad of probabilities in increasing order of
+// bit index.
+type sparseTemp struct {
+	idx []int
+	val []float64
+	// scratch pads for merging
+	idx2 []int
+	val2 []float64
+}
+
+// minScale is the scale below which the entries of a sparseVel are rescaled.
+const minScale = 1e-100
+
+/*
+SetSparseVelocity switches the velocities of all particles to a sparse form
+when eps > 0 and back to the dense form otherwise. In the sparse form only bits
+with a flipping probability that differs from the uniform blur of the bits by
+at least eps are stored, so memory grows with the number of active bits rather
+than MaxLen(), and the omega decay is applied lazily through a scale factor for
+each particle. The Combiner must satisfy Combine(p,0) = p. The bits to flip are
+chosen with the same probabilities as the dense form apart from entries within
+eps of the blur being merged into it, but with a different use of random
+numbers, so runs are not the same.
+This is intended for very large parameter spaces.
+*/
+func (pso *Pso) SetSparseVelocity(eps float64) {
+	if eps > 0 {
+		pso.eps = eps
+		for i := range pso.Pt {
+			p := &pso.Pt[i]
+			if p.sv == nil {
+				p.sv = &sparseVel{scale: 1.0}
+				p.sv.setDense(p.vel, eps)
+				p.vel = nil
+			}
+		}
+		pso.tempVel = nil
+		if pso.stemp == nil {
+			pso.stemp = new(sparseTemp)
+		}
+		return
+	}
+	if pso.stemp == nil {
+		return
+	}
+	pso.eps = 0
+	for i := range pso.Pt {
+		p := &pso.Pt[i]
+		if p.sv != nil {
+			p.vel = p.sv.dense(pso.maxLen)
+			p.sv = nil
+		}
+	}
+	pso.tempVel = make([]float64, pso.maxLen)
+	pso.stemp = nil
+}
+
+// SparseVelocity returns the cut off set by SetSparseVelocity() or 0 when the
+// velocities are dense.
+func (pso *Pso) SparseVelocity() float64 { return pso.eps }
+
+/*
+ActiveBits returns the number of bits of the ith particle that have their
+velocity stored individually, which is MaxLen() for the dense form.
+*/
+func (pso *Pso) ActiveBits(i int) int {
+	p := &pso.Pt[i]
+	if p.sv == nil {
+		return len(p.vel)
+	}
+	return len(p.sv.idx)
+}
+
+// velocity returns a dense copy of the particle's velocity of length n.
+func (p *Particle) velocity(n int) []float64 {
+	if p.sv == nil {
+		return append([]float64(nil), p.vel...)
+	}
+	return p.sv.dense(n)
+}
+
+// setVelocity sets the particle's velocity from the dense vel.
+func (p *Particle) setVelocity(vel []float64, eps float64) {
+	if p.sv == nil {
+		copy(p.vel, vel)
+		return
+	}
+	p.sv.setDense(vel, eps)
+}
+
+// state returns the stored form of v.
+func (v *sparseVel) state() *sparseState {
+	return &sparseState{
+		Idx:   append([]int(nil), v.idx...),
+		U:     append([]float64(nil), v.u...),
+		Scale: v.scale,
+		Bg:    v.bg}
+}
+
+// valid returns true if the entries of ss are in increasing order of bit
+// index below n.
+func (ss *sparseState) valid(n int) bool {
+	if len(ss.Idx) != len(ss.U) {
+		return false
+	}
+	for k, j := range ss.Idx {
+		if j < 0 || j >= n || (k > 0 && j <= ss.Idx[k-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// setSparseState sets the particle's velocity of length n from the stored
+// sparse form ss.
+func (p *Particle) setSparseState(ss *sparseState, n int) {
+	v := &sparseVel{
+		idx:   append([]int(nil), ss.Idx...),
+		u:     append([]float64(nil), ss.U...),
+		scale: ss.Scale,
+		bg:    ss.Bg}
+	if p.sv == nil {
+		copy(p.vel, v.dense(n))
+		return
+	}
+	*p.sv = *v
+}
+
+// zeroVel sets the velocity of bit j to zero.
+func (p *Particle) zeroVel(j int) {
+	if p.sv == nil {
+		p.vel[j] = 0.0
+		return
+	}
+	v := p.sv
+	k := sort.SearchInts(v.idx, j)
+	if k < len(v.idx) && v.idx[k] == j {
+		v.u[k] = 0.0
+		return
+	}
+	v.idx = append(v.idx, 0)
+	v.u = append(v.u, 0)
+	copy(v.idx[k+1:], v.idx[k:])
+	copy(v.u[k+1:], v.u[k:])
+	v.idx[k] = j
+	v.u[k] = 0.0
+}
+
+// clearVel sets the velocity of all bits to zero.
+func (p *Particle) clearVel() {
+	if p.sv == nil {
+		for j := range p.vel {
+			p.vel[j] = 0.0
+		}
+		return
+	}
+	p.sv.idx = p.sv.idx[:0]
+	p.sv.u = p.sv.u[:0]
+	p.sv.scale = 1.0
+	p.sv.bg = 0.0
+}
+
+// dense returns the velocity as a dense array of length n.
+func (v *sparseVel) dense(n int) []float64 {
+	vel := make([]float64, n)
+	for j := range vel {
+		vel[j] = v.bg
+	}
+	for k, j := range v.idx {
+		vel[j] = v.scale * v.u[k]
+	}
+	return vel
+}
+
+// setDense sets the velocity from the dense vel with no background.
+func (v *sparseVel) setDense(vel []float64, eps float64) {
+	v.idx = v.idx[:0]
+	v.u = v.u[:0]
+	v.scale = 1.0
+	v.bg = 0.0
+	for j, x := range vel {
+		if x >= eps {
+			v.idx = append(v.idx, j)
+			v.u = append(v.u, x)
+		}
+	}
+}
+
+// blur combines prob with the velocity of every bit.
+func (v *sparseVel) blur(prob float64, c Combiner) {
+	for k := range v.u {
+		v.u[k] = c.Combine(v.scale*v.u[k], prob)
+	}
+	v.scale = 1.0
+	v.bg = c.Combine(v.bg, prob)
+}
+
+// reset empties the scratch pad.
+func (t *sparseTemp) reset() {
+	t.idx = t.idx[:0]
+	t.val = t.val[:0]
+}
+
+// add combines prob with the probability of each bit j where z has a 1 and
+// j < n.
+func (t *sparseTemp) add(z *big.Int, n int, prob float64, c Combiner) {
+	t.idx2, t.idx = t.idx, t.idx2[:0]
+	t.val2, t.val = t.val, t.val2[:0]
+	a := 0
+	ForEachBit(z, func(j int) {
+		if j >= n {
+			return
+		}
+		for a < len(t.idx2) && t.idx2[a] < j {
+			t.idx = append(t.idx, t.idx2[a])
+			t.val = append(t.val, t.val2[a])
+			a++
+		}
+		if a < len(t.idx2) && t.idx2[a] == j {
+			t.idx = append(t.idx, j)
+			t.val = append(t.val, c.Combine(t.val2[a], prob))
+			a++
+		} else {
+			t.idx = append(t.idx, j)
+			t.val = append(t.val, c.Combine(0.0, prob))
+		}
+	})
+	t.idx = append(t.idx, t.idx2[a:]...)
+	t.val = append(t.val, t.val2[a:]...)
+}
+
+/*
+combine reduces the velocity by omega and then combines it with the
+probabilities in t. Entries that are within eps of the background are dropped.
+*/
+func (v *sparseVel) combine(t *sparseTemp, omega, eps float64, c Combiner,
+	idx []int, u []float64) ([]int, []float64) {
+	v.scale *= omega
+	v.bg *= omega
+	if v.scale < minScale {
+		for k := range v.u {
+			v.u[k] *= v.scale
+		}
+		v.scale = 1.0
+	}
+	idx, u = idx[:0], u[:0]
+	keep := func(j int, x float64) {
+		if math.Abs(x-v.bg) >= eps {
+			idx = append(idx, j)
+			u = append(u, x/v.scale)
+		}
+	}
+	a := 0
+	for b, j := range t.idx {
+		for a < len(v.idx) && v.idx[a] < j {
+			keep(v.idx[a], v.scale*v.u[a])
+			a++
+		}
+		if a < len(v.idx) && v.idx[a] == j {
+			keep(j, c.Combine(v.scale*v.u[a], t.val[b]))
+			a++
+		} else {
+			keep(j, c.Combine(v.bg, t.val[b]))
+		}
+	}
+	for ; a < len(v.idx); a++ {
+		keep(v.idx[a], v.scale*v.u[a])
+	}
+	// swap in the new entries and return the old ones for reuse
+	idx, v.idx = v.idx, idx
+	u, v.u = v.u, u
+	return idx, u
+}
+
+/*
+flip sets bit j of the mask words ws for each bit j to flip among n bits and
+zeroes its velocity. Bits with an entry flip with their own probability and
+the other bits with the background probability, which are found by geometric
+skipping so only the flipped bits cost anything. The flipped background bits
+are given entries of zero. The entries are rebuilt in idx and u and the old
+ones are returned for reuse.
+*/
+func (v *sparseVel) flip(rnd *rand.Rand, ws []big.Word, n int,
+	idx []int, u []float64) ([]int, []float64) {
+	next := -1
+	lq := math.Log1p(-v.bg)
+	// skip moves next on to the next background bit to flip
+	skip := func() {
+		if v.bg <= 0.0 {
+			next = n
+			return
+		}
+		s := math.Floor(math.Log(1.0-rnd.Float64()) / lq)
+		if s >= float64(n) {
+			next = n
+			return
+		}
+		next += 1 + int(s)
+	}
+	skip()
+	idx, u = idx[:0], u[:0]
+	for k, j := range v.idx {
+		for next < j {
+			setWordBit(ws, next)
+			idx = append(idx, next)
+			u = append(u, 0.0)
+			skip()
+		}
+		if next == j {
+			// an entry has its own probability
+			skip()
+		}
+		x := v.u[k]
+		if rnd.Float64() < v.scale*x {
+			x = 0.0
+			setWordBit(ws, j)
+		}
+		idx = append(idx, j)
+		u = append(u, x)
+	}
+	for next < n {
+		setWordBit(ws, next)
+		idx = append(idx, next)
+		u = append(u, 0.0)
+		skip()
+	}
+	idx, v.idx = v.idx, idx
+	u, v.u = v.u, u
+	return idx, u
+}
@@ -0,0 +1,120 @@
+package setpso_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/mathrgo/setpso"
+	"github.com/mathrgo/setpso/fun/futil"
+	"github.com/mathrgo/setpso/fun/subsetsum"
+)
+
+// flipRate returns the mean number of bits of the current parameters that
+// change in each update of a GPso on a subset sum problem of n items. When
+// converged is true all the particles start from the same Parameters with no
+// velocity and no pull to their targets so only the target blur flips bits.
+func flipRate(n int, eps float64, converged bool) float64 {
+	p0 := setpso.NewPso(20, subsetsum.New(n, 20, 3142), 578)
+	if converged {
+		x := new(big.Int).Set(p0.CurrentTry(0).Parameter())
+		for i := 0; i < p0.Nparticles(); i++ {
+			p0.SetParticle(i, x)
+		}
+		p0.Heuristics().SetFloat(setpso.LfactorHeuristic, 0)
+		p0.Heuristics().SetFloat(setpso.PhiHeuristic, 0)
+	}
+	p0.SetSparseVelocity(eps)
+	pso := setpso.NewGPso(p0)
+	prev := make([]*big.Int, pso.Nparticles())
+	diff := new(big.Int)
+	total := 0
+	nupdate := 40
+	for k := 0; k < nupdate; k++ {
+		for i := range prev {
+			prev[i] = new(big.Int).Set(pso.CurrentTry(i).Parameter())
+		}
+		pso.Update()
+		for i := range prev {
+			total += setpso.CardinalSize(diff.Xor(prev[i], pso.CurrentTry(i).Parameter()))
+		}
+	}
+	return float64(total) / float64(nupdate*len(prev))
+}
+
+// newSparsePso returns a Pso with sparse velocities on a large subset sum
+// problem whose particles start from small random subsets so they differ in
+// few bits.
+func newSparsePso(f setpso.Fun, sd int64) *setpso.Pso {
+	seeds := make([]*big.Int, 20)
+	for i := range seeds {
+		seeds[i] = randomBits(f.MaxLen(), 1000, int64(i))
+	}
+	p0, err := setpso.NewPsoInit(20, f, sd,
+		&setpso.SeededInit{Seeds: seeds, Rest: &setpso.RandomInit{}})
+	if err != nil {
+		panic(err)
+	}
+	p0.SetSparseVelocity(1e-3)
+	return p0
+}
+
+func TestSparseVelocity(t *testing.T) {
+	f := subsetsum.New(20000, 20, 3142)
+	p0 := newSparsePso(f, 578)
+	pso := setpso.NewGPso(p0)
+	start := f.NewTry()
+	f.Copy(start, pso.LocalBestTry(pso.BestParticle()))
+	for i := 0; i < 100; i++ {
+		pso.Update()
+	}
+	// bits flipped by the blur keep an entry until their probability is back
+	// within eps of the blur, which here leaves up to about a sixth active
+	for i := 0; i < pso.Nparticles(); i++ {
+		if a := pso.ActiveBits(i); a > f.MaxLen()/5 {
+			t.Errorf("particle %d has %d active bits out of %d", i, a, f.MaxLen())
+		}
+	}
+	if f.Cmp(pso.LocalBestTry(pso.BestParticle()), start, futil.CostMode) >= 0.0 {
+		t.Errorf("sparse run did not improve on the initial best")
+	}
+
+	// checkpoints restore the sparse velocities
+	var buf bytes.Buffer
+	if err := pso.Checkpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	q := setpso.NewGPso(newSparsePso(subsetsum.New(20000, 20, 3142), 99))
+	if err := q.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		pso.Update()
+		q.Update()
+	}
+	samePersonalBests(t, pso, q)
+
+	// the sparse form flips bits at about the same rate as the dense form
+	// also when the swarm has converged and only a background blur below eps
+	// flips bits
+	for _, c := range []struct {
+		n         int
+		converged bool
+	}{{2000, false}, {20000, true}} {
+		dense, sparse := flipRate(c.n, 0, c.converged), flipRate(c.n, 1e-3, c.converged)
+		if sparse < 0.5*dense || sparse > 2*dense {
+			t.Errorf("converged=%t: sparse flip rate %f differs from dense flip rate %f",
+				c.converged, sparse, dense)
+		}
+	}
+}
+
+func BenchmarkGPsoUpdateSparse(b *testing.B) {
+	p0 := setpso.NewPso(20, subsetsum.New(4096, 20, 3142), 578)
+	p0.SetSparseVelocity(1e-3)
+	pso := setpso.NewGPso(p0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pso.Update()
+	}
+}